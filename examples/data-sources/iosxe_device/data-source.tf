@@ -0,0 +1,5 @@
+data "iosxe_device" "example" {}
+
+output "platform" {
+  value = "${data.iosxe_device.example.pid} ${data.iosxe_device.example.version}"
+}
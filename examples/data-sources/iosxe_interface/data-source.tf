@@ -0,0 +1,8 @@
+data "iosxe_interface" "example" {
+  type = "GigabitEthernet"
+  name = "1/0/1"
+}
+
+output "debug" {
+  value = data.iosxe_interface.example.description
+}
@@ -0,0 +1,7 @@
+data "iosxe_interface_port_channel" "example" {
+  name = "69"
+}
+
+output "debug" {
+  value = data.iosxe_interface_port_channel.example.description
+}
@@ -0,0 +1,7 @@
+data "iosxe_interface_vlan" "example" {
+  vlanid = 666
+}
+
+output "debug" {
+  value = data.iosxe_interface_vlan.example.ip
+}
@@ -0,0 +1,8 @@
+data "iosxe_interfaces" "uplinks" {
+  type              = "GigabitEthernet"
+  description_regex = "^uplink"
+}
+
+output "uplinks" {
+  value = [for i in data.iosxe_interfaces.uplinks.interfaces : "${i.type}${i.name}"]
+}
@@ -0,0 +1,8 @@
+data "iosxe_restconf" "example" {
+  path  = "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR"
+  depth = 2
+}
+
+output "debug" {
+  value = data.iosxe_restconf.example.attributes["definition.rd"]
+}
@@ -1,7 +0,0 @@
-data "iosxe_vlan" "example" {
-  vlanid = 666
-}
-
-output "debug" {
-  value = data.iosxe_vlan.example
-}
\ No newline at end of file
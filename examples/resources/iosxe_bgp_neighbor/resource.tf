@@ -16,5 +16,6 @@ resource "iosxe_bgp_neighbor" "example" {
 }
 
 output "debug" {
-  value = iosxe_bgp_neighbor.example
+  value     = iosxe_bgp_neighbor.example
+  sensitive = true
 }
\ No newline at end of file
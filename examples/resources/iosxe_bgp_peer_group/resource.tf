@@ -0,0 +1,22 @@
+resource "iosxe_bgp_router" "example" {
+  as = 65420
+}
+
+resource "iosxe_bgp_peer_group" "example" {
+  as             = iosxe_bgp_router.example.as
+  name           = "IBGP"
+  remote_as      = 65420
+  update_source  = "Loopback0"
+  send_community = "both"
+
+  route_map {
+    direction = "in"
+    name      = "RM_IBGP_IN"
+  }
+}
+
+resource "iosxe_bgp_neighbor" "example" {
+  as         = iosxe_bgp_router.example.as
+  ip         = "10.255.0.2"
+  peer_group = iosxe_bgp_peer_group.example.name
+}
@@ -1,8 +1,32 @@
 resource "iosxe_bgp_router" "example" {
-  as                   = 65420
-  log_neighbor_changes = true
+  as                       = 65420
+  log_neighbor_changes     = true
+  router_id                = "10.255.0.1"
+  default_local_preference = 200
+  graceful_restart         = true
+
+  bestpath {
+    as_path_multipath_relax = true
+    med_missing_as_worst    = true
+  }
+
+  timers {
+    keepalive_interval = 10
+    holdtime           = 30
+  }
+
+  listen_range {
+    range      = "10.0.0.0/24"
+    peer_group = "SPOKES"
+  }
+
+  address_family {
+    name                          = "ipv4-unicast"
+    maximum_paths                 = 4
+    default_information_originate = true
+  }
 }
 
 output "debug" {
   value = iosxe_bgp_router.example
-}
\ No newline at end of file
+}
@@ -0,0 +1,16 @@
+resource "iosxe_cli" "example" {
+  commands = [
+    "ip access-list standard TF-TEST",
+    " 10 permit 10.0.0.1",
+    " 20 permit 10.0.0.2",
+    "ip domain lookup source-interface Vlan666",
+  ]
+  delete_commands = [
+    "no ip access-list standard TF-TEST",
+    "no ip domain lookup source-interface Vlan666",
+  ]
+}
+
+output "debug" {
+  value = iosxe_cli.example
+}
@@ -0,0 +1,14 @@
+resource "iosxe_l2_vlan" "example" {
+  vlanid = 420
+  name   = "IoT"
+}
+
+resource "iosxe_interface_vlan" "example" {
+  vlanid      = iosxe_l2_vlan.example.vlanid
+  description = "IoT"
+  ip          = "192.168.42.1/24"
+}
+
+resource "iosxe_commit" "example" {
+  depends_on = [iosxe_l2_vlan.example, iosxe_interface_vlan.example]
+}
@@ -0,0 +1,18 @@
+resource "iosxe_restconf" "example" {
+  path = "Cisco-IOS-XE-native:native/ntp"
+  attributes = jsonencode({
+    "Cisco-IOS-XE-ntp:server" = {
+      "server-list" = [
+        { "ip-address" = "10.0.0.1" },
+        { "ip-address" = "10.0.0.2", "prefer" = [null] },
+      ]
+    }
+  })
+  lists = {
+    "server/server-list" = "ip-address"
+  }
+}
+
+output "debug" {
+  value = iosxe_restconf.example
+}
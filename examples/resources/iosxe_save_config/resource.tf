@@ -0,0 +1,10 @@
+resource "iosxe_l2_vlan" "example" {
+  vlanid = 420
+  name   = "IoT"
+}
+
+resource "iosxe_save_config" "example" {
+  triggers = {
+    vlan = iosxe_l2_vlan.example.name
+  }
+}
@@ -0,0 +1,307 @@
+package netconf
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NETCONF replies carry no type information and don't tell a list of one
+// entry from a container, so the conversion to RESTCONF JSON follows the
+// IOS-XE native model as used by the provider's resources. Lists are the
+// elements in listKeys, leaves are numbers only if named in numberLeaves and
+// empty elements are empty leaves, encoded as [null], unless named in
+// emptyContainers. Entries are looked up in these tables by "parent/name"
+// first, then by name.
+
+// listKeys maps the lists to their key leaves.
+var listKeys = map[string][]string{
+	"definition":                    {"name"},
+	"route-target/export":           {"asn-ip"},
+	"route-target/import":           {"asn-ip"},
+	"address/secondary":             {"address"},
+	"GigabitEthernet":               {"name"},
+	"TenGigabitEthernet":            {"name"},
+	"FortyGigabitEthernet":          {"name"},
+	"TwentyFiveGigE":                {"name"},
+	"HundredGigE":                   {"name"},
+	"TwoGigabitEthernet":            {"name"},
+	"FiveGigabitEthernet":           {"name"},
+	"AppGigabitEthernet":            {"name"},
+	"Loopback":                      {"name"},
+	"Tunnel":                        {"name"},
+	"Vlan":                          {"name"},
+	"Port-channel":                  {"name"},
+	"VirtualPortGroup":              {"name"},
+	"vlan-list":                     {"id"},
+	"router/bgp":                    {"id"},
+	"neighbor":                      {"id"},
+	"neighbor/prefix-list":          {"inout"},
+	"neighbor/route-map":            {"inout"},
+	"listen/range":                  {"network-range"},
+	"no-vrf/ipv4":                   {"af-name"},
+	"no-vrf/ipv6":                   {"af-name"},
+	"no-vrf/vpnv4":                  {"af-name"},
+	"no-vrf/vpnv6":                  {"af-name"},
+	"no-vrf/l2vpn":                  {"af-name"},
+	"with-vrf/ipv4":                 {"af-name"},
+	"with-vrf/ipv6":                 {"af-name"},
+	"ipv4/vrf":                      {"name"},
+	"ipv6/vrf":                      {"name"},
+	"modules-state/module":          {"name", "revision"},
+	"device-inventory":              {"hw-type", "hw-dev-index"},
+	"flash-list-ordered-by-user":    {"flash-leaf"},
+	"filename-list-ordered-by-user": {"filename"},
+}
+
+// augments maps nodes of the native model added by other modules to their
+// module, for paths that leave out the module name, which IOS-XE accepts over
+// RESTCONF. Other nodes have to be module qualified as in RFC 8040.
+var augments = map[string]string{
+	"vlan/vlan-list": "Cisco-IOS-XE-vlan",
+	"router/bgp":     "Cisco-IOS-XE-bgp",
+}
+
+// numberLeaves are the integer leaves.
+var numberLeaves = map[string]bool{
+	"id":                       true,
+	"remote-as":                true,
+	"as-no":                    true,
+	"max-hop":                  true,
+	"routes":                   true,
+	"number":                   true,
+	"vlan-id":                  true,
+	"keepalive-interval":       true,
+	"holdtime":                 true,
+	"minimum-neighbor-hold":    true,
+	"maxas-limit":              true,
+	"maxcommunity-limit":       true,
+	"update-delay":             true,
+	"hw-dev-index":             true,
+	"enctype":                  true,
+	"hops":                     true,
+	"max-prefix-no":            true,
+	"maximum-prefix/threshold": true,
+	"allowas-in/as-number":     true,
+}
+
+// emptyContainers are the containers without mandatory content.
+var emptyContainers = map[string]bool{
+	"address-family/ipv4": true,
+	"address-family/ipv6": true,
+	"default-originate":   true,
+	"next-hop-self":       true,
+	"peer-group":          true,
+	"remove-private-as":   true,
+}
+
+// stringLeaves are never decoded as booleans.
+var stringLeaves = map[string]bool{
+	"name":        true,
+	"description": true,
+	"result":      true,
+	"message":     true,
+}
+
+var integer = regexp.MustCompile(`^-?[0-9]+$`)
+
+func hint(m map[string]bool, parent, name string) bool {
+	return m[parent+"/"+name] || m[name]
+}
+
+// ListKeys returns the key leaves of the list name below parent, and whether
+// name is a known list.
+func ListKeys(parent, name string) ([]string, bool) {
+	if k, ok := listKeys[parent+"/"+name]; ok {
+		return k, true
+	}
+	k, ok := listKeys[name]
+	return k, ok
+}
+
+// Namespace returns the XML namespace of a YANG module.
+func Namespace(module string) string {
+	switch {
+	case module == "cisco-ia":
+		return "http://cisco.com/yang/cisco-ia"
+	case strings.HasPrefix(module, "ietf-"):
+		return "urn:ietf:params:xml:ns:yang:" + module
+	case strings.HasPrefix(module, "openconfig-"):
+		return "http://openconfig.net/yang/" + strings.TrimPrefix(module, "openconfig-")
+	}
+	return "http://cisco.com/ns/yang/" + module
+}
+
+// Module returns the YANG module of an XML namespace.
+func Module(ns string) string {
+	if strings.HasPrefix(ns, "http://openconfig.net/yang/") {
+		return "openconfig-" + strings.TrimPrefix(ns, "http://openconfig.net/yang/")
+	}
+	if i := strings.LastIndexAny(ns, "/:"); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+// splitName splits a JSON member name into its module, if qualified, and local name.
+func splitName(name string) (string, string) {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// AppendJSON appends the elements encoding the JSON member name with value v
+// to parent. Unqualified names take the namespace of parent.
+func AppendJSON(parent *Node, name string, v interface{}) error {
+	module, local := splitName(name)
+	ns := parent.Name.Space
+	if module != "" {
+		ns = Namespace(module)
+	}
+
+	if l, ok := v.([]interface{}); ok {
+		for _, e := range l {
+			if _, nested := e.([]interface{}); nested {
+				return fmt.Errorf("nested arrays are not valid in %s", name)
+			}
+			if err := AppendJSON(parent, name, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	n := NewNode(ns, local)
+	parent.Children = append(parent.Children, n)
+
+	switch t := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		keys, _ := ListKeys(parent.Name.Local, local)
+		for _, k := range memberOrder(t, keys) {
+			if err := AppendJSON(n, k, t[k]); err != nil {
+				return err
+			}
+		}
+	case string:
+		n.Text = t
+	case float64:
+		n.Text = strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		n.Text = fmt.Sprint(t)
+	}
+
+	return nil
+}
+
+// memberOrder returns the members of m with the list keys first, as NETCONF
+// requires, and the rest sorted.
+func memberOrder(m map[string]interface{}, keys []string) []string {
+	if len(keys) == 0 {
+		keys = []string{"name", "id", "af-name"}
+	}
+
+	first := []string{}
+	rest := []string{}
+	for k := range m {
+		_, local := splitName(k)
+		isKey := false
+		for _, key := range keys {
+			if local == key {
+				isKey = true
+			}
+		}
+		if isKey {
+			first = append(first, k)
+		} else {
+			rest = append(rest, k)
+		}
+	}
+	sort.Slice(first, func(i, j int) bool {
+		return keyIndex(first[i], keys) < keyIndex(first[j], keys)
+	})
+	sort.Strings(rest)
+
+	return append(first, rest...)
+}
+
+func keyIndex(name string, keys []string) int {
+	_, local := splitName(name)
+	for i, k := range keys {
+		if k == local {
+			return i
+		}
+	}
+	return len(keys)
+}
+
+// JSONName returns the RESTCONF JSON member name of n, qualified with its
+// module if its namespace differs from parentNS.
+func JSONName(n *Node, parentNS string) string {
+	if n.Name.Space == "" || n.Name.Space == parentNS {
+		return n.Name.Local
+	}
+	return Module(n.Name.Space) + ":" + n.Name.Local
+}
+
+// JSONValue converts the content of n, below an element named parent, to its
+// RESTCONF JSON value.
+func JSONValue(n *Node, parent string) interface{} {
+	local := n.Name.Local
+	if n.IsLeaf() {
+		text := n.Text
+		switch {
+		case text == "" && hint(emptyContainers, parent, local):
+			return map[string]interface{}{}
+		case text == "":
+			return []interface{}{nil}
+		case hint(numberLeaves, parent, local) && integer.MatchString(text):
+			return json.Number(text)
+		case (text == "true" || text == "false") && !hint(stringLeaves, parent, local):
+			return text == "true"
+		}
+		return text
+	}
+
+	m := map[string]interface{}{}
+	for _, group := range groupChildren(n) {
+		c := group[0]
+		name := JSONName(c, n.Name.Space)
+		// leaves never are list entries, e.g. the choice of interface
+		// update-source/interface/Loopback is a leaf named after a list
+		_, isList := ListKeys(local, c.Name.Local)
+		if len(group) == 1 && (!isList || c.IsLeaf()) {
+			m[name] = JSONValue(c, local)
+			continue
+		}
+		l := make([]interface{}, 0, len(group))
+		for _, e := range group {
+			l = append(l, JSONValue(e, local))
+		}
+		m[name] = l
+	}
+
+	return m
+}
+
+// groupChildren returns the children of n grouped by name, in the order of
+// their first appearance.
+func groupChildren(n *Node) [][]*Node {
+	groups := [][]*Node{}
+	index := map[string]int{}
+	for _, c := range n.Children {
+		key := c.Name.Space + " " + c.Name.Local
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], c)
+	}
+	return groups
+}
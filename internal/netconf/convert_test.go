@@ -0,0 +1,99 @@
+package netconf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	body := `{
+		"Cisco-IOS-XE-native:Vlan": {
+			"name": 100,
+			"description": "true",
+			"shutdown": [null],
+			"ip": {"address": {"primary": {"address": "10.0.0.1", "mask": "255.255.255.0"}, "secondary": [{"address": "10.0.1.1", "mask": "255.255.255.0", "secondary": [null]}]}},
+			"Cisco-IOS-XE-ethernet:channel-group": {"number": 1, "mode": "active"}
+		}
+	}`
+	v := map[string]interface{}{}
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	root := NewNode(Namespace("Cisco-IOS-XE-native"), "interface")
+	if err := AppendJSON(root, "Cisco-IOS-XE-native:Vlan", v["Cisco-IOS-XE-native:Vlan"]); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	x := root.Children[0].String()
+	if !strings.HasPrefix(x, `<Vlan xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-native"><name>100</name><channel-group xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-ethernet">`) {
+		t.Fatalf("expected the key first and namespaces per module, got %s", x)
+	}
+
+	n, err := ParseXML([]byte(root.String()))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	got := JSONValue(n.Children[0], "interface")
+	want := map[string]interface{}{
+		"name":        "100",
+		"description": "true",
+		"shutdown":    []interface{}{nil},
+		"ip": map[string]interface{}{"address": map[string]interface{}{
+			"primary":   map[string]interface{}{"address": "10.0.0.1", "mask": "255.255.255.0"},
+			"secondary": []interface{}{map[string]interface{}{"address": "10.0.1.1", "mask": "255.255.255.0", "secondary": []interface{}{nil}}},
+		}},
+		"Cisco-IOS-XE-ethernet:channel-group": map[string]interface{}{"number": json.Number("1"), "mode": "active"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestJSONValue_interfaceChoice(t *testing.T) {
+	n, err := ParseXML([]byte(`<neighbor xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-bgp"><id>7.7.7.7</id><update-source><interface><Loopback>0</Loopback></interface></update-source></neighbor>`))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	got := JSONValue(n, "bgp")
+	want := map[string]interface{}{
+		"id":            "7.7.7.7",
+		"update-source": map[string]interface{}{"interface": map[string]interface{}{"Loopback": "0"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestMessageFraming(t *testing.T) {
+	for _, chunked := range []bool{false, true} {
+		var b bytes.Buffer
+		msgs := []string{`<rpc message-id="1"><get/></rpc>`, `<rpc message-id="2"><a>]]></a></rpc>`}
+		for _, m := range msgs {
+			if err := WriteMessage(&b, []byte(m), chunked); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+		}
+
+		r := bufio.NewReader(&b)
+		for _, m := range msgs {
+			if !chunked && strings.Contains(m, "]]>]]>") {
+				continue
+			}
+			got, err := ReadMessage(r, chunked)
+			if err != nil {
+				t.Fatalf("chunked=%t: %s", chunked, err)
+			}
+			if string(got) != m {
+				t.Fatalf("chunked=%t: expected %q, got %q", chunked, m, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package netconf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RPCError is an rpc-error returned by the server.
+type RPCError struct {
+	Type     string
+	Tag      string
+	Severity string
+	Path     string
+	Message  string
+}
+
+func (e *RPCError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Tag
+	}
+	if e.Path != "" {
+		return fmt.Sprintf("%s (%s)", msg, e.Path)
+	}
+	return msg
+}
+
+// replyError returns the first rpc-error of reply with severity error.
+func replyError(reply *Node) error {
+	for _, n := range reply.ChildrenNamed("rpc-error") {
+		e := &RPCError{}
+		if c := n.Child("error-type"); c != nil {
+			e.Type = strings.TrimSpace(c.Text)
+		}
+		if c := n.Child("error-tag"); c != nil {
+			e.Tag = strings.TrimSpace(c.Text)
+		}
+		if c := n.Child("error-severity"); c != nil {
+			e.Severity = strings.TrimSpace(c.Text)
+		}
+		if c := n.Child("error-path"); c != nil {
+			e.Path = strings.TrimSpace(c.Text)
+		}
+		if c := n.Child("error-message"); c != nil {
+			e.Message = strings.TrimSpace(c.Text)
+		}
+		if e.Severity == "warning" {
+			continue
+		}
+		return e
+	}
+	return nil
+}
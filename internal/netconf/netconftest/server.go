@@ -0,0 +1,757 @@
+// Package netconftest provides an in-memory IOS-XE NETCONF over SSH server
+// for tests.
+//
+// The server keeps the running and, if enabled, the candidate datastore as XML
+// trees and supports get, get-config with subtree filters, edit-config with
+// the merge, replace, create, delete and remove operations, commit including
+// confirmed commits, cancel-commit, discard-changes, lock and unlock. Other
+// operations are passed to the
+// handlers registered with HandleRPC. It knows nothing about the YANG schema:
+// elements with a name, id, af-name, address, asn-ip or inout leaf are list
+// entries matched on it, everything else is matched by name, so leaf-lists
+// are not supported.
+package netconftest
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/poroping/terraform-provider-iosxe/internal/netconf"
+	"golang.org/x/crypto/ssh"
+)
+
+// keyLeaves are the leaves identifying list entries, in order of precedence.
+var keyLeaves = []string{"name", "id", "af-name", "address", "asn-ip", "inout"}
+
+// RPCHandler implements an operation. It gets the operation element and
+// returns the content of the reply, nil for <ok/>. Errors are returned as an
+// operation-failed rpc-error.
+type RPCHandler func(op *netconf.Node) ([]*netconf.Node, error)
+
+// Server is a fake IOS-XE NETCONF device.
+type Server struct {
+	// Host is the host:port to configure the provider with.
+	Host string
+
+	listener net.Listener
+	config   *ssh.ServerConfig
+	// candidate enables the candidate datastore instead of a writable running one
+	candidate bool
+
+	mu        sync.Mutex
+	running   *netconf.Node
+	pending   *netconf.Node
+	lockedBy  int
+	sessions  int
+	conns     []net.Conn
+	requests  []string
+	rpcs      map[string]RPCHandler
+	forbidden map[string]error
+	drop      map[string]bool
+
+	// confirmedBy is the session of a confirmed commit waiting to be
+	// confirmed, reverted to backup when revert fires or the session ends
+	confirmedBy int
+	backup      *netconf.Node
+	revert      *time.Timer
+}
+
+// NewServer starts a server on a local port. With candidate set it announces
+// the candidate datastore, and the running datastore is only written by commit.
+// Any username and password are accepted.
+func NewServer(candidate bool) *Server {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		panic(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Server{
+		Host:      l.Addr().String(),
+		listener:  l,
+		config:    config,
+		candidate: candidate,
+		running:   netconf.NewNode(netconf.BaseNamespace, "data"),
+		rpcs:      map[string]RPCHandler{},
+		forbidden: map[string]error{},
+		drop:      map[string]bool{},
+	}
+	s.pending = s.running.Copy()
+	go s.serve()
+
+	return s
+}
+
+// Close stops the server and ends all sessions.
+func (s *Server) Close() {
+	s.listener.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.conns {
+		c.Close()
+	}
+}
+
+// Merge merges the config elements in the XML document config, e.g.
+// `<native xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-native">...</native>`,
+// into the running and candidate datastores.
+func (s *Server) Merge(config string) error {
+	n, err := netconf.ParseXML([]byte("<config>" + config + "</config>"))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := edit(s.running, n, "merge"); err != nil {
+		return err
+	}
+	s.pending = s.running.Copy()
+
+	return nil
+}
+
+// Get returns the element of the running datastore at the RESTCONF path,
+// e.g. "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR". List entries are
+// matched on any of their leaves.
+func (s *Server) Get(path string) (*netconf.Node, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur := s.running
+	for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		name := seg
+		keys := []string{}
+		if i := strings.Index(seg, "="); i >= 0 {
+			name = seg[:i]
+			for _, k := range strings.Split(seg[i+1:], ",") {
+				key, err := url.PathUnescape(k)
+				if err != nil {
+					return nil, false
+				}
+				keys = append(keys, key)
+			}
+		}
+		if i := strings.Index(name, ":"); i >= 0 {
+			name = name[i+1:]
+		}
+
+		var next *netconf.Node
+		for _, c := range cur.ChildrenNamed(name) {
+			if hasLeaves(c, keys) {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return nil, false
+		}
+		cur = next
+	}
+
+	return cur.Copy(), true
+}
+
+// Exists reports whether the running datastore holds an element at path.
+func (s *Server) Exists(path string) bool {
+	_, ok := s.Get(path)
+	return ok
+}
+
+// Running returns the running datastore as XML.
+func (s *Server) Running() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.running.String()
+}
+
+// HandleRPC registers h for the operation name, e.g. "cisco-ia:save-config".
+// Handlers run with the server lock held and must not call its methods.
+func (s *Server) HandleRPC(name string, h RPCHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rpcs[name] = h
+}
+
+// Fail makes the operation fail with err until Fail is called with a nil err.
+func (s *Server) Fail(operation string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		delete(s.forbidden, operation)
+		return
+	}
+	s.forbidden[operation] = err
+}
+
+// DropAfter makes the server end the session sending operation right after
+// replying to it, the next time only.
+func (s *Server) DropAfter(operation string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.drop[operation] = true
+}
+
+// Confirming reports whether a confirmed commit waits to be confirmed.
+func (s *Server) Confirming() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.confirmedBy != 0
+}
+
+// Requests returns the names of the operations received so far.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.requests...)
+}
+
+// ResetRequests clears the recorded operations.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = nil
+}
+
+func (s *Server) serve() {
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, c)
+		s.mu.Unlock()
+		go s.serveConn(c)
+	}
+}
+
+func (s *Server) serveConn(c net.Conn) {
+	defer c.Close()
+
+	_, chans, reqs, err := ssh.NewServerConn(c, s.config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		ch, requests, err := nc.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				var subsystem struct{ Name string }
+				ok := req.Type == "subsystem" && ssh.Unmarshal(req.Payload, &subsystem) == nil && subsystem.Name == "netconf"
+				req.Reply(ok, nil)
+				if ok {
+					go s.serveSession(ch)
+				}
+			}
+		}()
+	}
+}
+
+func (s *Server) serveSession(ch ssh.Channel) {
+	defer ch.Close()
+
+	s.mu.Lock()
+	s.sessions++
+	id := s.sessions
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.endSession(id)
+	}()
+
+	caps := []string{netconf.CapabilityBase10, netconf.CapabilityBase11}
+	if s.candidate {
+		caps = append(caps, netconf.CapabilityCandidate, netconf.CapabilityConfirmedCommit11)
+	} else {
+		caps = append(caps, "urn:ietf:params:netconf:capability:writable-running:1.0")
+	}
+	hello := netconf.NewNode(netconf.BaseNamespace, "hello")
+	capabilities := netconf.NewNode(netconf.BaseNamespace, "capabilities")
+	for _, c := range caps {
+		n := netconf.NewNode(netconf.BaseNamespace, "capability")
+		n.Text = c
+		capabilities.Children = append(capabilities.Children, n)
+	}
+	session := netconf.NewNode(netconf.BaseNamespace, "session-id")
+	session.Text = fmt.Sprint(id)
+	hello.Children = append(hello.Children, capabilities, session)
+	if err := netconf.WriteMessage(ch, []byte(hello.String()), false); err != nil {
+		return
+	}
+
+	r := bufio.NewReader(ch)
+	b, err := netconf.ReadMessage(r, false)
+	if err != nil {
+		return
+	}
+	clientHello, err := netconf.ParseXML(b)
+	if err != nil {
+		return
+	}
+	clientCaps := []string{}
+	if c := clientHello.Child("capabilities"); c != nil {
+		for _, n := range c.ChildrenNamed("capability") {
+			clientCaps = append(clientCaps, strings.TrimSpace(n.Text))
+		}
+	}
+	chunked := netconf.HasCapability(clientCaps, netconf.CapabilityBase11)
+
+	for {
+		b, err := netconf.ReadMessage(r, chunked)
+		if err != nil {
+			return
+		}
+		rpc, err := netconf.ParseXML(b)
+		if err != nil || rpc.Name.Local != "rpc" || len(rpc.Children) != 1 {
+			return
+		}
+		op := rpc.Children[0]
+
+		reply := netconf.NewNode(netconf.BaseNamespace, "rpc-reply")
+		reply.SetAttr("", "message-id", rpc.Attr("", "message-id"))
+		content, err := s.handle(id, op)
+		if err != nil {
+			reply.Children = append(reply.Children, rpcError(err))
+		} else if content == nil {
+			reply.Children = append(reply.Children, netconf.NewNode(netconf.BaseNamespace, "ok"))
+		} else {
+			reply.Children = append(reply.Children, content...)
+		}
+
+		if err := netconf.WriteMessage(ch, []byte(reply.String()), chunked); err != nil {
+			return
+		}
+		if op.Name.Local == "close-session" || s.dropAfter(op.Name.Local) {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(session int, op *netconf.Node) ([]*netconf.Node, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, op.Name.Local)
+	if err, ok := s.forbidden[op.Name.Local]; ok {
+		return nil, &netconf.RPCError{Tag: "operation-failed", Message: err.Error()}
+	}
+
+	if op.Name.Space != netconf.BaseNamespace {
+		h, ok := s.rpcs[netconf.Module(op.Name.Space)+":"+op.Name.Local]
+		if !ok {
+			return nil, &netconf.RPCError{Tag: "operation-not-supported", Message: fmt.Sprintf("unknown operation %s", op.Name.Local)}
+		}
+		out, err := h(op)
+		if err != nil {
+			return nil, &netconf.RPCError{Tag: "operation-failed", Message: err.Error()}
+		}
+		return out, nil
+	}
+
+	switch op.Name.Local {
+	case "get", "get-config":
+		source := s.running
+		if op.Name.Local == "get-config" {
+			ds, err := s.datastore(op, "source")
+			if err != nil {
+				return nil, err
+			}
+			source = ds
+		}
+		data := netconf.NewNode(netconf.BaseNamespace, "data")
+		if f := op.Child("filter"); f != nil {
+			data.Children = filterChildren(source, f)
+		} else {
+			data.Children = source.Copy().Children
+		}
+		return []*netconf.Node{data}, nil
+	case "edit-config":
+		target, err := s.datastore(op, "target")
+		if err != nil {
+			return nil, err
+		}
+		if target == s.running && s.candidate {
+			return nil, &netconf.RPCError{Tag: "operation-not-supported", Message: "the running datastore is not writable"}
+		}
+		if s.lockedBy != 0 && s.lockedBy != session {
+			return nil, &netconf.RPCError{Tag: "in-use", Message: "the datastore is locked by another session"}
+		}
+		config := op.Child("config")
+		if config == nil {
+			return nil, &netconf.RPCError{Tag: "missing-element", Message: "config is missing"}
+		}
+		defaultOp := "merge"
+		if d := op.Child("default-operation"); d != nil {
+			defaultOp = strings.TrimSpace(d.Text)
+		}
+		// apply to a copy, so a failed edit leaves the datastore unchanged
+		work := target.Copy()
+		if err := edit(work, config, defaultOp); err != nil {
+			return nil, err
+		}
+		target.Children = work.Children
+		return nil, nil
+	case "commit":
+		if !s.candidate {
+			return nil, &netconf.RPCError{Tag: "operation-not-supported", Message: "no candidate datastore"}
+		}
+		if s.lockedBy != 0 && s.lockedBy != session {
+			return nil, &netconf.RPCError{Tag: "in-use", Message: "the datastore is locked by another session"}
+		}
+		if op.Child("confirmed") != nil {
+			timeout := 600
+			if t := op.Child("confirm-timeout"); t != nil {
+				v, err := strconv.Atoi(strings.TrimSpace(t.Text))
+				if err != nil || v < 1 {
+					return nil, &netconf.RPCError{Tag: "invalid-value", Message: "invalid confirm-timeout"}
+				}
+				timeout = v
+			}
+			if s.confirmedBy == 0 {
+				s.backup = s.running.Copy()
+				s.confirmedBy = session
+			} else {
+				s.revert.Stop()
+			}
+			s.revert = time.AfterFunc(time.Duration(timeout)*time.Second, func() {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				s.rollback()
+			})
+		} else if s.confirmedBy != 0 {
+			s.revert.Stop()
+			s.confirmedBy = 0
+		}
+		s.running = s.pending.Copy()
+		return nil, nil
+	case "cancel-commit":
+		if s.confirmedBy == 0 {
+			return nil, &netconf.RPCError{Tag: "operation-failed", Message: "no confirmed commit is pending"}
+		}
+		s.rollback()
+		return nil, nil
+	case "discard-changes":
+		if !s.candidate {
+			return nil, &netconf.RPCError{Tag: "operation-not-supported", Message: "no candidate datastore"}
+		}
+		s.pending = s.running.Copy()
+		return nil, nil
+	case "lock":
+		if _, err := s.datastore(op, "target"); err != nil {
+			return nil, err
+		}
+		if s.lockedBy != 0 && s.lockedBy != session {
+			return nil, &netconf.RPCError{Tag: "lock-denied", Message: fmt.Sprintf("locked by session %d", s.lockedBy)}
+		}
+		s.lockedBy = session
+		return nil, nil
+	case "unlock":
+		if s.lockedBy != session {
+			return nil, &netconf.RPCError{Tag: "operation-failed", Message: "not locked by this session"}
+		}
+		s.lockedBy = 0
+		return nil, nil
+	case "close-session":
+		s.endSession(session)
+		return nil, nil
+	}
+
+	return nil, &netconf.RPCError{Tag: "operation-not-supported", Message: fmt.Sprintf("unknown operation %s", op.Name.Local)}
+}
+
+// endSession releases the lock of session, dropping the changes in the
+// candidate datastore, and reverts its confirmed commit.
+func (s *Server) endSession(session int) {
+	if s.confirmedBy == session {
+		s.rollback()
+	}
+	if s.lockedBy == session {
+		s.lockedBy = 0
+		if s.candidate {
+			s.pending = s.running.Copy()
+		}
+	}
+}
+
+// dropAfter reports whether the session sending operation is to be ended.
+func (s *Server) dropAfter(operation string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	drop := s.drop[operation]
+	delete(s.drop, operation)
+	return drop
+}
+
+// rollback reverts a confirmed commit waiting to be confirmed, also dropping
+// the changes in the candidate datastore.
+func (s *Server) rollback() {
+	if s.confirmedBy == 0 {
+		return
+	}
+	s.revert.Stop()
+	s.confirmedBy = 0
+	s.running = s.backup
+	s.pending = s.running.Copy()
+}
+
+// datastore returns the datastore named in the role element of op.
+func (s *Server) datastore(op *netconf.Node, role string) (*netconf.Node, error) {
+	r := op.Child(role)
+	if r == nil || len(r.Children) != 1 {
+		return nil, &netconf.RPCError{Tag: "missing-element", Message: fmt.Sprintf("%s is missing", role)}
+	}
+	switch r.Children[0].Name.Local {
+	case "running":
+		return s.running, nil
+	case "candidate":
+		if s.candidate {
+			return s.pending, nil
+		}
+	}
+	return nil, &netconf.RPCError{Tag: "invalid-value", Message: fmt.Sprintf("unknown datastore %s", r.Children[0].Name.Local)}
+}
+
+func rpcError(err error) *netconf.Node {
+	e, ok := err.(*netconf.RPCError)
+	if !ok {
+		e = &netconf.RPCError{Tag: "operation-failed", Message: err.Error()}
+	}
+	leaf := func(name, text string) *netconf.Node {
+		n := netconf.NewNode(netconf.BaseNamespace, name)
+		n.Text = text
+		return n
+	}
+	return netconf.NewNode(netconf.BaseNamespace, "rpc-error",
+		leaf("error-type", "application"),
+		leaf("error-tag", e.Tag),
+		leaf("error-severity", "error"),
+		leaf("error-message", e.Message),
+	)
+}
+
+// entryKey returns the name and value of the leaf identifying the list entry n.
+func entryKey(n *netconf.Node) (string, string, bool) {
+	for _, k := range keyLeaves {
+		if c := n.Child(k); c != nil && c.IsLeaf() {
+			return k, c.Text, true
+		}
+	}
+	return "", "", false
+}
+
+// same reports whether a and b are the same node: the same element and, for
+// list entries, the same key.
+func same(a, b *netconf.Node) bool {
+	if a.Name != b.Name {
+		return false
+	}
+	name, value, ok := entryKey(b)
+	if !ok {
+		return true
+	}
+	c := a.Child(name)
+	return c != nil && c.Text == value
+}
+
+func find(parent *netconf.Node, n *netconf.Node) int {
+	for i, c := range parent.Children {
+		if same(c, n) {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasLeaves reports whether every value is held by one of the leaves of n.
+func hasLeaves(n *netconf.Node, values []string) bool {
+	for _, v := range values {
+		found := false
+		for _, c := range n.Children {
+			if c.IsLeaf() && c.Text == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// edit applies the children of config to dst.
+func edit(dst *netconf.Node, config *netconf.Node, defaultOp string) error {
+	for _, c := range config.Children {
+		op := c.Attr(netconf.BaseNamespace, "operation")
+		if op == "" {
+			op = defaultOp
+		}
+		i := find(dst, c)
+
+		switch op {
+		case "delete", "remove":
+			if i < 0 {
+				if op == "delete" {
+					return &netconf.RPCError{Tag: "data-missing", Message: fmt.Sprintf("%s does not exist", c.Name.Local)}
+				}
+				continue
+			}
+			dst.Children = append(dst.Children[:i], dst.Children[i+1:]...)
+		case "create", "replace":
+			if i >= 0 && op == "create" {
+				return &netconf.RPCError{Tag: "data-exists", Message: fmt.Sprintf("%s already exists", c.Name.Local)}
+			}
+			n := stripOperations(c)
+			if i >= 0 {
+				dst.Children[i] = n
+			} else {
+				dst.Children = append(dst.Children, n)
+			}
+		case "merge", "none":
+			if i < 0 {
+				if op == "none" && !c.IsLeaf() {
+					n := &netconf.Node{Name: c.Name}
+					dst.Children = append(dst.Children, n)
+					if err := edit(n, c, defaultOp); err != nil {
+						return err
+					}
+					continue
+				}
+				dst.Children = append(dst.Children, stripOperations(c))
+				continue
+			}
+			if c.IsLeaf() {
+				if op == "merge" {
+					dst.Children[i] = stripOperations(c)
+				}
+				continue
+			}
+			if err := edit(dst.Children[i], c, defaultOp); err != nil {
+				return err
+			}
+		default:
+			return &netconf.RPCError{Tag: "bad-attribute", Message: fmt.Sprintf("unknown operation %s", op)}
+		}
+	}
+
+	return nil
+}
+
+// stripOperations returns a copy of n without operation attributes.
+func stripOperations(n *netconf.Node) *netconf.Node {
+	c := &netconf.Node{Name: n.Name, Text: n.Text}
+	for _, a := range n.Attrs {
+		if a.Name != (xml.Name{Space: netconf.BaseNamespace, Local: "operation"}) {
+			c.Attrs = append(c.Attrs, a)
+		}
+	}
+	for _, ch := range n.Children {
+		c.Children = append(c.Children, stripOperations(ch))
+	}
+	return c
+}
+
+// filterChildren returns the children of data selected by the subtree filter f.
+func filterChildren(data *netconf.Node, f *netconf.Node) []*netconf.Node {
+	r := []*netconf.Node{}
+	for _, fc := range f.Children {
+		for _, dc := range data.Children {
+			if dc.Name.Local != fc.Name.Local || (fc.Name.Space != "" && fc.Name.Space != dc.Name.Space) {
+				continue
+			}
+			if n := filterNode(dc, fc); n != nil {
+				r = append(r, n)
+			}
+		}
+	}
+	return r
+}
+
+// filterNode applies the filter element f to the matching element n as in
+// RFC 6241 section 6: content match nodes have to match, and without selection
+// or containment nodes the whole element is selected.
+func filterNode(n *netconf.Node, f *netconf.Node) *netconf.Node {
+	if f.IsLeaf() {
+		if f.Text != "" && (!n.IsLeaf() || n.Text != f.Text) {
+			return nil
+		}
+		return n.Copy()
+	}
+
+	matches := []*netconf.Node{}
+	selects := &netconf.Node{}
+	for _, fc := range f.Children {
+		if fc.IsLeaf() && strings.TrimSpace(fc.Text) != "" {
+			matches = append(matches, fc)
+			continue
+		}
+		selects.Children = append(selects.Children, fc)
+	}
+
+	r := &netconf.Node{Name: n.Name, Attrs: n.Attrs}
+	for _, m := range matches {
+		c := n.Child(m.Name.Local)
+		if c == nil || !c.IsLeaf() || c.Text != strings.TrimSpace(m.Text) {
+			return nil
+		}
+		r.Children = append(r.Children, c.Copy())
+	}
+	if len(selects.Children) == 0 {
+		return n.Copy()
+	}
+
+	selected := filterChildren(n, selects)
+	if len(selected) == 0 {
+		return nil
+	}
+	r.Children = append(r.Children, selected...)
+
+	return r
+}
@@ -0,0 +1,81 @@
+package netconftest
+
+import (
+	"testing"
+
+	"github.com/poroping/terraform-provider-iosxe/internal/netconf"
+)
+
+const testData = `<data>
+<native xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-native">
+<vrf>
+<definition><name>A</name><rd>1:1</rd></definition>
+<definition><name>B</name><rd>1:2</rd></definition>
+</vrf>
+<hostname>sw1</hostname>
+</native>
+</data>`
+
+func testParse(t *testing.T, s string) *netconf.Node {
+	n, err := netconf.ParseXML([]byte(s))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return n
+}
+
+func TestServer_filter(t *testing.T) {
+	data := testParse(t, testData)
+
+	cases := []struct {
+		filter string
+		want   string
+	}{
+		{
+			filter: `<filter><native xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-native"><vrf><definition><name>B</name></definition></vrf></native></filter>`,
+			want:   `<native xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-native"><vrf><definition><name>B</name><rd>1:2</rd></definition></vrf></native>`,
+		},
+		{
+			filter: `<filter><native xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-native"><hostname/></native></filter>`,
+			want:   `<native xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-native"><hostname>sw1</hostname></native>`,
+		},
+		{
+			filter: `<filter><native xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-native"><vrf><definition><name>C</name></definition></vrf></native></filter>`,
+			want:   ``,
+		},
+	}
+
+	for _, c := range cases {
+		got := ""
+		for _, n := range filterChildren(data, testParse(t, c.filter)) {
+			got += n.String()
+		}
+		if got != c.want {
+			t.Errorf("filter %s: expected %s, got %s", c.filter, c.want, got)
+		}
+	}
+}
+
+func TestServer_edit(t *testing.T) {
+	data := testParse(t, testData)
+
+	config := testParse(t, `<config xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0"><native xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-native"><vrf>
+<definition nc:operation="delete"><name>A</name></definition>
+<definition nc:operation="replace"><name>B</name><description>b</description></definition>
+<definition><name>C</name><rd>1:3</rd></definition>
+</vrf><hostname>sw2</hostname></native></config>`)
+	if err := edit(data, config, "merge"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	want := `<data><native xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-native"><vrf><definition><name>B</name><description>b</description></definition><definition><name>C</name><rd>1:3</rd></definition></vrf><hostname>sw2</hostname></native></data>`
+	if got := data.String(); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	config = testParse(t, `<config xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0"><native xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-native"><vrf><definition nc:operation="delete"><name>A</name></definition></vrf></native></config>`)
+	err := edit(data, config, "merge")
+	if e, ok := err.(*netconf.RPCError); !ok || e.Tag != "data-missing" {
+		t.Fatalf("expected data-missing deleting a missing entry, got %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+// Package netconf implements a NETCONF over SSH client for IOS-XE and a
+// transport translating the provider's RESTCONF requests to it, for devices
+// where only NETCONF is enabled.
+package netconf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// DefaultPort is the IANA port of NETCONF over SSH.
+const DefaultPort = "830"
+
+const (
+	CapabilityBase10    = "urn:ietf:params:netconf:base:1.0"
+	CapabilityBase11    = "urn:ietf:params:netconf:base:1.1"
+	CapabilityCandidate = "urn:ietf:params:netconf:capability:candidate:1.0"
+
+	CapabilityConfirmedCommit10 = "urn:ietf:params:netconf:capability:confirmed-commit:1.0"
+	CapabilityConfirmedCommit11 = "urn:ietf:params:netconf:capability:confirmed-commit:1.1"
+
+	// endOfMessage delimits messages in base:1.0 framing
+	endOfMessage = "]]>]]>"
+)
+
+// Session is a NETCONF session. RPCs are sent one at a time.
+type Session struct {
+	conn    *ssh.Client
+	channel ssh.Channel
+	r       *bufio.Reader
+
+	// Capabilities are the capabilities announced by the server.
+	Capabilities []string
+
+	chunked bool
+
+	mu        sync.Mutex
+	messageID int
+	closed    bool
+}
+
+// KnownHosts returns a callback verifying host keys against
+// ~/.ssh/known_hosts. Devices on port 830 are listed as "[host]:830".
+func KnownHosts() (ssh.HostKeyCallback, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+}
+
+// Dial connects to the NETCONF subsystem at addr, a host with an optional
+// port, and exchanges hellos.
+func Dial(ctx context.Context, addr string, cfg *ssh.ClientConfig) (*Session, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), DefaultPort)
+	}
+
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		nc.SetDeadline(deadline)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(nc, addr, cfg)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	conn := ssh.NewClient(c, chans, reqs)
+
+	s, err := newSession(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	nc.SetDeadline(time.Time{})
+
+	return s, nil
+}
+
+func newSession(conn *ssh.Client) (*Session, error) {
+	channel, reqs, err := conn.OpenChannel("session", nil)
+	if err != nil {
+		return nil, err
+	}
+	go ssh.DiscardRequests(reqs)
+
+	ok, err := channel.SendRequest("subsystem", true, ssh.Marshal(struct{ Name string }{"netconf"}))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("the netconf subsystem was refused, check netconf-yang is enabled")
+	}
+
+	s := &Session{
+		conn:    conn,
+		channel: channel,
+		r:       bufio.NewReader(channel),
+	}
+	if err := s.hello(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Session) hello() error {
+	hello := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><hello xmlns="%s"><capabilities><capability>%s</capability><capability>%s</capability></capabilities></hello>`,
+		BaseNamespace, CapabilityBase10, CapabilityBase11)
+	if err := WriteMessage(s.channel, []byte(hello), false); err != nil {
+		return err
+	}
+
+	b, err := ReadMessage(s.r, false)
+	if err != nil {
+		return fmt.Errorf("error reading hello. %s", err)
+	}
+	n, err := ParseXML(b)
+	if err != nil {
+		return fmt.Errorf("error decoding hello. %s", err)
+	}
+	if caps := n.Child("capabilities"); caps != nil {
+		for _, c := range caps.ChildrenNamed("capability") {
+			s.Capabilities = append(s.Capabilities, strings.TrimSpace(c.Text))
+		}
+	}
+	s.chunked = s.HasCapability(CapabilityBase11)
+
+	return nil
+}
+
+// HasCapability reports whether the server announced the capability uri,
+// ignoring its parameters.
+func (s *Session) HasCapability(uri string) bool {
+	return HasCapability(s.Capabilities, uri)
+}
+
+// HasCapability reports whether caps holds the capability uri, ignoring its parameters.
+func HasCapability(caps []string, uri string) bool {
+	for _, c := range caps {
+		if i := strings.Index(c, "?"); i >= 0 {
+			c = c[:i]
+		}
+		if c == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// RPC sends the operation op and returns the rpc-reply. An rpc-error with
+// severity error is returned as *RPCError. When ctx is done before the reply
+// arrives the session is closed, as the reply can't be told apart from later
+// ones anymore.
+func (s *Session) RPC(ctx context.Context, op *Node) (*Node, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, fmt.Errorf("netconf session closed")
+	}
+
+	s.messageID++
+	id := strconv.Itoa(s.messageID)
+	rpc := NewNode(BaseNamespace, "rpc", op)
+	rpc.SetAttr("", "message-id", id)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.conn.Close()
+		case <-done:
+		}
+	}()
+
+	reply, err := s.roundTrip(rpc)
+	if ctx.Err() != nil {
+		s.closed = true
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		s.closed = true
+		s.conn.Close()
+		return nil, err
+	}
+	if got := reply.Attr("", "message-id"); got != id {
+		s.closed = true
+		s.conn.Close()
+		return nil, fmt.Errorf("expected reply to message %s, got %q", id, got)
+	}
+
+	if err := replyError(reply); err != nil {
+		return reply, err
+	}
+
+	return reply, nil
+}
+
+func (s *Session) roundTrip(rpc *Node) (*Node, error) {
+	if err := WriteMessage(s.channel, []byte(rpc.String()), s.chunked); err != nil {
+		return nil, err
+	}
+	b, err := ReadMessage(s.r, s.chunked)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseXML(b)
+}
+
+// Closed reports whether the session can no longer be used.
+func (s *Session) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+// Close ends the session and its connection.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		s.messageID++
+		rpc := NewNode(BaseNamespace, "rpc", NewNode(BaseNamespace, "close-session"))
+		rpc.SetAttr("", "message-id", strconv.Itoa(s.messageID))
+		WriteMessage(s.channel, []byte(rpc.String()), s.chunked)
+	}
+
+	return s.conn.Close()
+}
+
+// WriteMessage writes msg in base:1.1 chunked framing if chunked is set,
+// base:1.0 end-of-message framing otherwise.
+func WriteMessage(w io.Writer, msg []byte, chunked bool) error {
+	var b bytes.Buffer
+	if chunked {
+		fmt.Fprintf(&b, "\n#%d\n", len(msg))
+		b.Write(msg)
+		b.WriteString("\n##\n")
+	} else {
+		b.Write(msg)
+		b.WriteString(endOfMessage)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// ReadMessage reads a message framed as written by WriteMessage.
+func ReadMessage(r *bufio.Reader, chunked bool) ([]byte, error) {
+	if !chunked {
+		var b bytes.Buffer
+		for {
+			line, err := r.ReadBytes('>')
+			b.Write(line)
+			if bytes.HasSuffix(b.Bytes(), []byte(endOfMessage)) {
+				return bytes.TrimSpace(b.Bytes()[:b.Len()-len(endOfMessage)]), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	for {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if header == "\n" {
+			// the newline starting the chunk header
+			header, err = r.ReadString('\n')
+			if err != nil {
+				return nil, err
+			}
+		}
+		header = strings.TrimSuffix(header, "\n")
+		if header == "##" {
+			return b.Bytes(), nil
+		}
+		if !strings.HasPrefix(header, "#") {
+			return nil, fmt.Errorf("invalid chunk header %q", header)
+		}
+		size, err := strconv.Atoi(header[1:])
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid chunk size %q", header[1:])
+		}
+		if _, err := io.CopyN(&b, r, int64(size)); err != nil {
+			return nil, err
+		}
+	}
+}
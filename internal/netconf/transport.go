@@ -0,0 +1,881 @@
+package netconf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	dataPath       = "/restconf/data/"
+	operationsPath = "/restconf/operations/"
+)
+
+// Transport is an http.RoundTripper serving RESTCONF requests over NETCONF,
+// so that clients written for RESTCONF can manage devices with only NETCONF
+// enabled. It connects to the host of the request URL, on port 830 unless the
+// host has one, with the basic auth credentials of the request.
+//
+// Reads are get-config operations on the running datastore with a subtree
+// filter for the path. Writes are edit-config operations, on the candidate
+// datastore followed by a commit if the device supports it. All operations of
+// a Transport share one session and are sent one at a time.
+//
+// With ConfirmedCommit set the writes are left in the candidate datastore,
+// which is locked from the first write until Confirm or Cancel, and reads are
+// served from it until CommitConfirmed commits them all at once.
+type Transport struct {
+	// HostKeyCallback verifies the host key of the device.
+	HostKeyCallback ssh.HostKeyCallback
+	// ConfirmedCommit holds the writes back for CommitConfirmed.
+	ConfirmedCommit bool
+
+	mu      sync.Mutex
+	session *Session
+	// login is the address and credentials session was opened with
+	login string
+	// locked is set while session holds the candidate lock with writes not
+	// yet confirmed, confirming once they are committed waiting for Confirm
+	locked     bool
+	confirming bool
+	// lost is the error for the session ending while locked, reported by
+	// the next CommitConfirmed or Confirm
+	lost error
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, err := t.open(req)
+	if err != nil {
+		return nil, err
+	}
+
+	status, resp, err := t.serve(req.Context(), s, req, body)
+	if err != nil {
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) {
+			return nil, err
+		}
+		status, resp = errorResponse(rpcErr)
+	}
+
+	header := http.Header{}
+	if len(resp) > 0 {
+		header.Set("Content-Type", "application/yang-data+json")
+	}
+	if req.Method == http.MethodHead {
+		resp = nil
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(resp)),
+		ContentLength: int64(len(resp)),
+		Request:       req,
+	}, nil
+}
+
+// open returns the session for the request, replacing a closed session or
+// one opened for another device or user.
+func (t *Transport) open(req *http.Request) (*Session, error) {
+	user, password, _ := req.BasicAuth()
+	login := strings.Join([]string{req.URL.Host, user, password}, "\x00")
+
+	if t.session != nil && !t.session.Closed() && t.login == login {
+		return t.session, nil
+	}
+	if t.session != nil {
+		t.session.Close()
+		t.session = nil
+	}
+	if t.locked {
+		// the device discards the candidate and reverts a confirmed commit
+		// when the session ends
+		t.locked, t.confirming = false, false
+		t.lost = fmt.Errorf("the netconf session to %s ended before the changes were confirmed, the device discards them", req.URL.Host)
+		return nil, t.lost
+	}
+
+	if t.HostKeyCallback == nil {
+		return nil, fmt.Errorf("no host key verification configured for netconf")
+	}
+	cfg := &ssh.ClientConfig{
+		User: user,
+		Auth: []ssh.AuthMethod{
+			ssh.Password(password),
+			ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			}),
+		},
+		HostKeyCallback: t.HostKeyCallback,
+	}
+	s, err := Dial(req.Context(), req.URL.Host, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open netconf session to %s. %s", req.URL.Host, err)
+	}
+	t.session = s
+	t.login = login
+
+	return s, nil
+}
+
+// CloseIdleConnections closes the session, unless it holds writes not yet
+// confirmed.
+func (t *Transport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.session != nil && !t.locked {
+		t.session.Close()
+		t.session = nil
+	}
+}
+
+// CommitConfirmed commits the writes held in the candidate datastore with a
+// confirmed commit, which the device reverts unless Confirm follows within
+// timeout. It does nothing if there are none. After an error the writes are
+// still held, Cancel drops them.
+func (t *Transport) CommitConfirmed(ctx context.Context, timeout time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.takeLost(); err != nil {
+		return err
+	}
+	if !t.locked || t.confirming {
+		return nil
+	}
+
+	seconds := NewNode(BaseNamespace, "confirm-timeout")
+	seconds.Text = strconv.Itoa(int(timeout.Seconds()))
+	op := NewNode(BaseNamespace, "commit", NewNode(BaseNamespace, "confirmed"), seconds)
+	if _, err := t.session.RPC(ctx, op); err != nil {
+		return err
+	}
+	t.confirming = true
+
+	return nil
+}
+
+// Confirm confirms the commit of CommitConfirmed and releases the candidate.
+// It fails if the session ended in between, the device has reverted the
+// commit then.
+func (t *Transport) Confirm(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.takeLost(); err != nil {
+		return err
+	}
+	if !t.confirming {
+		return nil
+	}
+	if t.session.Closed() {
+		t.locked, t.confirming = false, false
+		return fmt.Errorf("the netconf session ended before the commit was confirmed, the device reverts it")
+	}
+
+	if _, err := t.session.RPC(ctx, NewNode(BaseNamespace, "commit")); err != nil {
+		return err
+	}
+	t.locked, t.confirming = false, false
+	if _, err := t.session.RPC(ctx, datastoreOp("unlock", "target", "candidate")); err != nil {
+		return fmt.Errorf("the commit is confirmed, but the candidate could not be unlocked. %s", err)
+	}
+
+	return nil
+}
+
+// Cancel discards the writes held in the candidate datastore and reverts a
+// commit waiting for Confirm by ending the session.
+func (t *Transport) Cancel() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lost = nil
+	if !t.locked {
+		return
+	}
+	t.locked, t.confirming = false, false
+	t.session.Close()
+	t.session = nil
+}
+
+func (t *Transport) takeLost() error {
+	err := t.lost
+	t.lost = nil
+	return err
+}
+
+// source is the datastore reads are served from, the candidate while it
+// holds writes not yet committed.
+func (t *Transport) source() string {
+	if t.locked && !t.confirming {
+		return "candidate"
+	}
+	return "running"
+}
+
+// edit applies config, held in the candidate datastore with ConfirmedCommit.
+func (t *Transport) edit(ctx context.Context, s *Session, config *Node) error {
+	if !t.ConfirmedCommit {
+		return edit(ctx, s, config)
+	}
+	if t.confirming {
+		return fmt.Errorf("a confirmed commit is waiting to be confirmed")
+	}
+
+	if !t.locked {
+		if !s.HasCapability(CapabilityCandidate) {
+			return fmt.Errorf("confirmed commits need the candidate datastore, which the device does not support")
+		}
+		if !s.HasCapability(CapabilityConfirmedCommit10) && !s.HasCapability(CapabilityConfirmedCommit11) {
+			return fmt.Errorf("the device does not support confirmed commits")
+		}
+		if _, err := s.RPC(ctx, datastoreOp("lock", "target", "candidate")); err != nil {
+			return err
+		}
+		// drop changes other sessions left in the candidate
+		if _, err := s.RPC(ctx, NewNode(BaseNamespace, "discard-changes")); err != nil {
+			s.RPC(ctx, datastoreOp("unlock", "target", "candidate"))
+			return err
+		}
+		t.locked = true
+	}
+
+	_, err := s.RPC(ctx, editConfig("candidate", config))
+	return err
+}
+
+func (t *Transport) serve(ctx context.Context, s *Session, req *http.Request, body []byte) (int, []byte, error) {
+	path := req.URL.EscapedPath()
+
+	if strings.HasPrefix(path, operationsPath) && req.Method == http.MethodPost {
+		name, err := url.PathUnescape(strings.TrimPrefix(path, operationsPath))
+		if err != nil {
+			return 0, nil, invalidValue(err.Error())
+		}
+		return t.rpc(ctx, s, name, body)
+	}
+
+	if !strings.HasPrefix(path, dataPath) {
+		return 0, nil, notFound()
+	}
+	segs, err := parsePath(strings.TrimPrefix(path, dataPath))
+	if err != nil {
+		return 0, nil, invalidValue(err.Error())
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return t.read(ctx, s, segs, req.URL.Query(), req.Method == http.MethodHead)
+	case http.MethodPut:
+		return t.write(ctx, s, segs, body, "replace")
+	case http.MethodPatch:
+		return t.write(ctx, s, segs, body, "merge")
+	case http.MethodDelete:
+		return t.delete(ctx, s, segs)
+	}
+
+	return 0, nil, &RPCError{Tag: "operation-not-supported", Message: fmt.Sprintf("%s is not supported over netconf", req.Method)}
+}
+
+func (t *Transport) read(ctx context.Context, s *Session, segs []segment, query url.Values, head bool) (int, []byte, error) {
+	// the top level containers always exist
+	if head && len(segs) == 1 {
+		return http.StatusOK, nil, nil
+	}
+	if query.Get("fields") != "" {
+		return 0, nil, invalidValue("the fields query parameter is not supported over netconf")
+	}
+
+	operation := "get-config"
+	switch query.Get("content") {
+	case "", "config":
+	case "nonconfig", "all":
+		// state data can't be told apart from config without the schema
+		operation = "get"
+	default:
+		return 0, nil, invalidValue(fmt.Sprintf("invalid content %q", query.Get("content")))
+	}
+
+	nodes, _, err := get(ctx, s, operation, t.source(), segs)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(nodes) == 0 {
+		return 0, nil, notFound()
+	}
+
+	last := segs[len(segs)-1]
+	parent := ""
+	if len(segs) > 1 {
+		parent = segs[len(segs)-2].name
+	}
+	_, isList := ListKeys(parent, last.name)
+
+	var value interface{}
+	if len(last.keys) > 0 || (len(nodes) == 1 && !isList) {
+		value = JSONValue(nodes[0], parent)
+	} else {
+		l := []interface{}{}
+		for _, n := range nodes {
+			l = append(l, JSONValue(n, parent))
+		}
+		value = l
+	}
+
+	if v := query.Get("depth"); v != "" && v != "unbounded" {
+		depth, err := strconv.Atoi(v)
+		if err != nil || depth < 1 {
+			return 0, nil, invalidValue(fmt.Sprintf("invalid depth %q", v))
+		}
+		value = trimDepth(value, depth)
+	}
+
+	resp, err := json.Marshal(map[string]interface{}{Module(nodes[0].Name.Space) + ":" + last.name: value})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, resp, nil
+}
+
+// write replaces or merges the node at segs with the body.
+func (t *Transport) write(ctx context.Context, s *Session, segs []segment, body []byte, operation string) (int, []byte, error) {
+	name, value, err := decodeBody(body)
+	if err != nil {
+		return 0, nil, &RPCError{Tag: "malformed-message", Message: err.Error()}
+	}
+	last := segs[len(segs)-1]
+	if _, local := splitName(name); local != last.name {
+		return 0, nil, invalidValue(fmt.Sprintf("the payload member %s does not match the path", name))
+	}
+	if l, ok := value.([]interface{}); ok && len(last.keys) > 0 {
+		if len(l) != 1 {
+			return 0, nil, invalidValue(fmt.Sprintf("expected a single list entry for %s", last.name))
+		}
+		value = l[0]
+	}
+
+	nodes, keys, err := get(ctx, s, "get-config", t.source(), segs)
+	if err != nil {
+		return 0, nil, err
+	}
+	exists := len(nodes) > 0
+	if !exists && operation == "merge" {
+		return 0, nil, &RPCError{Tag: "data-missing", Message: "patch to a nonexistent resource"}
+	}
+
+	// a new list entry has its key leaves in the payload
+	if len(last.keys) > 0 && keys[len(segs)-1] == nil {
+		m, _ := value.(map[string]interface{})
+		keys[len(segs)-1] = jsonKeyNames(m, last.keys)
+		if keys[len(segs)-1] == nil {
+			return 0, nil, invalidValue(fmt.Sprintf("the payload does not hold the keys of %s", last.name))
+		}
+	}
+
+	config, parent, err := editTree(segs, keys, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := AppendJSON(parent, qualify(name, segs), value); err != nil {
+		return 0, nil, invalidValue(err.Error())
+	}
+	// a payload for a whole list has an element per entry
+	for _, target := range parent.Children {
+		if len(last.keys) > 0 {
+			setKeys(target, keys[len(segs)-1], last.keys)
+		}
+		target.SetAttr(BaseNamespace, "operation", operation)
+	}
+
+	if err := t.edit(ctx, s, config); err != nil {
+		return 0, nil, err
+	}
+
+	if exists {
+		return http.StatusNoContent, nil, nil
+	}
+	return http.StatusCreated, nil, nil
+}
+
+func (t *Transport) delete(ctx context.Context, s *Session, segs []segment) (int, []byte, error) {
+	keys := knownKeys(segs)
+	for i, seg := range segs {
+		if len(seg.keys) > 0 && keys[i] == nil {
+			// look up the key leaves of lists the conversion doesn't know
+			nodes, found, err := get(ctx, s, "get-config", t.source(), segs)
+			if err != nil {
+				return 0, nil, err
+			}
+			if len(nodes) == 0 {
+				return 0, nil, notFound()
+			}
+			keys = found
+			break
+		}
+	}
+
+	config, target, err := editTree(segs, keys, true)
+	if err != nil {
+		return 0, nil, err
+	}
+	target.SetAttr(BaseNamespace, "operation", "delete")
+
+	if err := t.edit(ctx, s, config); err != nil {
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) && rpcErr.Tag == "data-missing" {
+			return 0, nil, notFound()
+		}
+		return 0, nil, err
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// rpc invokes the operation name with the input in body.
+func (t *Transport) rpc(ctx context.Context, s *Session, name string, body []byte) (int, []byte, error) {
+	module, local := splitName(name)
+	if module == "" {
+		return 0, nil, invalidValue(fmt.Sprintf("operation %s is not module qualified", name))
+	}
+	op := NewNode(Namespace(module), local)
+
+	if len(body) > 0 {
+		_, input, err := decodeBody(body)
+		if err != nil {
+			return 0, nil, &RPCError{Tag: "malformed-message", Message: err.Error()}
+		}
+		m, _ := input.(map[string]interface{})
+		for _, k := range memberOrder(m, nil) {
+			if err := AppendJSON(op, k, m[k]); err != nil {
+				return 0, nil, invalidValue(err.Error())
+			}
+		}
+	}
+
+	reply, err := s.RPC(ctx, op)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	output := NewNode(Namespace(module), "output")
+	for _, c := range reply.Children {
+		if c.Name.Local != "ok" {
+			output.Children = append(output.Children, c)
+		}
+	}
+	if output.IsLeaf() {
+		return http.StatusNoContent, nil, nil
+	}
+
+	resp, err := json.Marshal(map[string]interface{}{module + ":output": JSONValue(output, local)})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, resp, nil
+}
+
+type segment struct {
+	ns   string
+	name string
+	keys []string
+}
+
+// parsePath splits an escaped RESTCONF data path into its segments. Segments
+// without a module take the namespace of the one before.
+func parsePath(path string) ([]segment, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	segs := []segment{}
+	ns := ""
+	for _, p := range strings.Split(path, "/") {
+		seg := segment{}
+		name := p
+		if i := strings.Index(p, "="); i >= 0 {
+			name = p[:i]
+			for _, k := range strings.Split(p[i+1:], ",") {
+				key, err := url.PathUnescape(k)
+				if err != nil {
+					return nil, err
+				}
+				seg.keys = append(seg.keys, key)
+			}
+		}
+		n, err := url.PathUnescape(name)
+		if err != nil {
+			return nil, err
+		}
+		module, local := splitName(n)
+		if module == "" && len(segs) > 0 {
+			module = augments[segs[len(segs)-1].name+"/"+local]
+		}
+		if module != "" {
+			ns = Namespace(module)
+		}
+		if ns == "" {
+			return nil, fmt.Errorf("the first path segment %s is not module qualified", n)
+		}
+		seg.ns = ns
+		seg.name = local
+		segs = append(segs, seg)
+	}
+
+	return segs, nil
+}
+
+// qualify returns the payload member name, qualified with the module of its
+// namespace if it is a top level node.
+func qualify(name string, segs []segment) string {
+	if module, _ := splitName(name); module != "" || len(segs) > 1 {
+		return name
+	}
+	return Module(segs[0].ns) + ":" + name
+}
+
+// knownKeys returns the key leaves of the list entries in segs as far as the
+// conversion tables know them.
+func knownKeys(segs []segment) [][]string {
+	keys := make([][]string, len(segs))
+	for i, seg := range segs {
+		if len(seg.keys) == 0 {
+			continue
+		}
+		parent := ""
+		if i > 0 {
+			parent = segs[i-1].name
+		}
+		if k, ok := ListKeys(parent, seg.name); ok && len(k) == len(seg.keys) {
+			keys[i] = k
+		}
+	}
+	return keys
+}
+
+// get reads the nodes at segs with a subtree filter, from the source datastore
+// for get-config. List entries with known
+// key leaves are selected by the filter, the others are matched against the
+// values of their leaves. It also returns the key leaves of the list entries
+// found on the way.
+func get(ctx context.Context, s *Session, operation, source string, segs []segment) ([]*Node, [][]string, error) {
+	keys := knownKeys(segs)
+
+	filter := NewNode(BaseNamespace, "filter")
+	filter.SetAttr("", "type", "subtree")
+	cur := filter
+	for i, seg := range segs {
+		n := NewNode(seg.ns, seg.name)
+		for j, k := range keys[i] {
+			n.Children = append(n.Children, &Node{Name: xml.Name{Space: seg.ns, Local: k}, Text: seg.keys[j]})
+		}
+		cur.Children = append(cur.Children, n)
+		cur = n
+	}
+
+	op := NewNode(BaseNamespace, operation)
+	if operation == "get-config" {
+		op.Children = append(op.Children, NewNode(BaseNamespace, "source", NewNode(BaseNamespace, source)))
+	}
+	op.Children = append(op.Children, filter)
+
+	reply, err := s.RPC(ctx, op)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := reply.Child("data")
+	if data == nil {
+		return nil, keys, nil
+	}
+
+	nodes := []*Node{data}
+	for i, seg := range segs {
+		found := []*Node{}
+		for _, c := range nodes[0].Children {
+			if c.Name.Local == seg.name && c.Name.Space == seg.ns {
+				found = append(found, c)
+			}
+		}
+		if len(seg.keys) > 0 {
+			entry := (*Node)(nil)
+			for _, c := range found {
+				if k := matchKeys(c, keys[i], seg.keys); k != nil {
+					entry = c
+					keys[i] = k
+					break
+				}
+			}
+			if entry == nil {
+				return nil, keys, nil
+			}
+			found = []*Node{entry}
+		}
+		if len(found) == 0 {
+			return nil, keys, nil
+		}
+		if i < len(segs)-1 && len(found) > 1 {
+			return nil, keys, invalidValue(fmt.Sprintf("%s is a list, select an entry", seg.name))
+		}
+		nodes = found
+	}
+
+	return nodes, keys, nil
+}
+
+// matchKeys returns the names of the leaves of entry holding values, looking
+// only at names if they are known.
+func matchKeys(entry *Node, names []string, values []string) []string {
+	if names != nil {
+		for i, name := range names {
+			c := entry.Child(name)
+			if c == nil || !c.IsLeaf() || c.Text != values[i] {
+				return nil
+			}
+		}
+		return names
+	}
+
+	r := []string{}
+	for _, v := range values {
+		found := ""
+		for _, c := range entry.Children {
+			if c.IsLeaf() && c.Text == v {
+				found = c.Name.Local
+				break
+			}
+		}
+		if found == "" {
+			return nil
+		}
+		r = append(r, found)
+	}
+	return r
+}
+
+// jsonKeyNames returns the names of the leaves of m holding values.
+func jsonKeyNames(m map[string]interface{}, values []string) []string {
+	r := []string{}
+	for _, v := range values {
+		found := ""
+		for _, k := range memberOrder(m, nil) {
+			switch m[k].(type) {
+			case map[string]interface{}, []interface{}:
+				continue
+			}
+			if fmt.Sprint(m[k]) == v {
+				_, found = splitName(k)
+				break
+			}
+		}
+		if found == "" {
+			return nil
+		}
+		r = append(r, found)
+	}
+	return r
+}
+
+// editTree returns the config element of an edit-config for segs, with the
+// ancestors of the node at segs and the node itself if withTarget is set. The
+// second value is the deepest element, which takes the payload of a write.
+func editTree(segs []segment, keys [][]string, withTarget bool) (*Node, *Node, error) {
+	config := NewNode(BaseNamespace, "config")
+	cur := config
+	for i, seg := range segs {
+		if i == len(segs)-1 && !withTarget {
+			break
+		}
+		if len(seg.keys) > 0 && keys[i] == nil {
+			return nil, nil, notFound()
+		}
+		n := NewNode(seg.ns, seg.name)
+		setKeys(n, keys[i], seg.keys)
+		cur.Children = append(cur.Children, n)
+		cur = n
+	}
+
+	return config, cur, nil
+}
+
+// setKeys puts the key leaves first in the list entry n, adding those missing.
+func setKeys(n *Node, names []string, values []string) {
+	for i := len(names) - 1; i >= 0; i-- {
+		key := &Node{Name: xml.Name{Space: n.Name.Space, Local: names[i]}, Text: values[i]}
+		children := []*Node{key}
+		for _, c := range n.Children {
+			if c.Name.Local == names[i] {
+				key.Text = c.Text
+				continue
+			}
+			children = append(children, c)
+		}
+		n.Children = children
+	}
+}
+
+// edit applies config, through the candidate datastore if the device has one.
+func edit(ctx context.Context, s *Session, config *Node) error {
+	if !s.HasCapability(CapabilityCandidate) {
+		_, err := s.RPC(ctx, editConfig("running", config))
+		return err
+	}
+
+	if _, err := s.RPC(ctx, datastoreOp("lock", "target", "candidate")); err != nil {
+		return err
+	}
+	defer s.RPC(ctx, datastoreOp("unlock", "target", "candidate"))
+
+	_, err := s.RPC(ctx, editConfig("candidate", config))
+	if err == nil {
+		_, err = s.RPC(ctx, NewNode(BaseNamespace, "commit"))
+	}
+	if err != nil {
+		s.RPC(ctx, NewNode(BaseNamespace, "discard-changes"))
+		return err
+	}
+
+	return nil
+}
+
+func editConfig(target string, config *Node) *Node {
+	op := datastoreOp("edit-config", "target", target)
+	op.Children = append(op.Children, config)
+	return op
+}
+
+func datastoreOp(operation, role, datastore string) *Node {
+	return NewNode(BaseNamespace, operation, NewNode(BaseNamespace, role, NewNode(BaseNamespace, datastore)))
+}
+
+// decodeBody returns the single top level member of a RESTCONF payload.
+func decodeBody(body []byte) (string, interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	m := map[string]interface{}{}
+	if err := dec.Decode(&m); err != nil {
+		return "", nil, err
+	}
+	if len(m) != 1 {
+		return "", nil, fmt.Errorf("expected exactly one top level member, got %d", len(m))
+	}
+	for k, v := range m {
+		return k, v, nil
+	}
+
+	return "", nil, nil
+}
+
+// trimDepth drops the members nested deeper than depth levels below v.
+func trimDepth(v interface{}, depth int) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		r := map[string]interface{}{}
+		for k, e := range t {
+			switch e.(type) {
+			case map[string]interface{}, []interface{}:
+				if depth <= 1 {
+					continue
+				}
+			}
+			r[k] = trimDepth(e, depth-1)
+		}
+		return r
+	case []interface{}:
+		r := []interface{}{}
+		for _, e := range t {
+			r = append(r, trimDepth(e, depth))
+		}
+		return r
+	}
+	return v
+}
+
+func notFound() error {
+	return &RPCError{Tag: "data-missing", Message: "uri keypath not found"}
+}
+
+func invalidValue(msg string) error {
+	return &RPCError{Tag: "invalid-value", Message: msg}
+}
+
+// errorStatus maps error tags to RESTCONF status codes as in RFC 8040,
+// except for data-missing, which IOS-XE reports as 404.
+var errorStatus = map[string]int{
+	"in-use":                  http.StatusConflict,
+	"lock-denied":             http.StatusConflict,
+	"resource-denied":         http.StatusConflict,
+	"data-exists":             http.StatusConflict,
+	"data-missing":            http.StatusNotFound,
+	"access-denied":           http.StatusForbidden,
+	"too-big":                 http.StatusRequestEntityTooLarge,
+	"operation-not-supported": http.StatusMethodNotAllowed,
+	"operation-failed":        http.StatusInternalServerError,
+	"partial-operation":       http.StatusInternalServerError,
+	"rollback-failed":         http.StatusInternalServerError,
+}
+
+// errorResponse encodes e as a RESTCONF error response.
+func errorResponse(e *RPCError) (int, []byte) {
+	status, ok := errorStatus[e.Tag]
+	if !ok {
+		status = http.StatusBadRequest
+	}
+
+	entry := map[string]interface{}{
+		"error-type":    e.Type,
+		"error-tag":     e.Tag,
+		"error-message": e.Error(),
+	}
+	if e.Type == "" {
+		entry["error-type"] = "application"
+	}
+	if e.Path != "" {
+		entry["error-path"] = e.Path
+	}
+	b, _ := json.Marshal(map[string]interface{}{
+		"errors": map[string]interface{}{
+			"error": []interface{}{entry},
+		},
+	})
+
+	return status, b
+}
@@ -0,0 +1,264 @@
+package netconf_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/poroping/terraform-provider-iosxe/internal/netconf"
+	"github.com/poroping/terraform-provider-iosxe/internal/netconf/netconftest"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
+	"golang.org/x/crypto/ssh"
+)
+
+func testClient(srv *netconftest.Server) *restconf.Client {
+	return testClientTransport(srv, &netconf.Transport{HostKeyCallback: ssh.InsecureIgnoreHostKey()})
+}
+
+func testClientTransport(srv *netconftest.Server, t *netconf.Transport) *restconf.Client {
+	return &restconf.Client{
+		HTTPClient: &http.Client{Transport: t},
+		Host:       srv.Host,
+		Username:   "admin",
+		Password:   "admin",
+	}
+}
+
+func testGet(t *testing.T, c *restconf.Client, path string) interface{} {
+	b, err := c.Get(context.Background(), path, nil)
+	if err != nil {
+		t.Fatalf("GET %s: %s", path, err)
+	}
+	v := map[string]interface{}{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("unable to decode %s: %s", b, err)
+	}
+	return v
+}
+
+func TestTransport_crud(t *testing.T) {
+	for _, candidate := range []bool{false, true} {
+		t.Run(fmt.Sprintf("candidate=%t", candidate), func(t *testing.T) {
+			srv := netconftest.NewServer(candidate)
+			defer srv.Close()
+			c := testClient(srv)
+			ctx := context.Background()
+			path := "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR"
+
+			if _, err := c.Get(ctx, path, nil); !restconf.IsNotFound(err) {
+				t.Fatalf("expected not found before create, got %v", err)
+			}
+
+			err := c.Put(ctx, path, []byte(`{"Cisco-IOS-XE-native:definition": {"rd": "1:1", "name": "FOOBAR", "address-family": {"ipv4": {}}, "route-target": {"export": [{"asn-ip": "1:1"}]}}}`))
+			if err != nil {
+				t.Fatalf("PUT: %s", err)
+			}
+			if !srv.Exists(path) {
+				t.Fatalf("%s not created: %s", path, srv.Running())
+			}
+
+			got := testGet(t, c, path)
+			want := map[string]interface{}{
+				"Cisco-IOS-XE-native:definition": map[string]interface{}{
+					"name":           "FOOBAR",
+					"rd":             "1:1",
+					"address-family": map[string]interface{}{"ipv4": map[string]interface{}{}},
+					"route-target": map[string]interface{}{
+						"export": []interface{}{map[string]interface{}{"asn-ip": "1:1"}},
+					},
+				},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("expected %v, got %v", want, got)
+			}
+
+			err = c.Patch(ctx, path, []byte(`{"Cisco-IOS-XE-native:definition": {"description": "foo"}}`))
+			if err != nil {
+				t.Fatalf("PATCH: %s", err)
+			}
+			got = testGet(t, c, path+"/description")
+			if want := map[string]interface{}{"Cisco-IOS-XE-native:description": "foo"}; !reflect.DeepEqual(got, want) {
+				t.Fatalf("expected %v, got %v", want, got)
+			}
+
+			if err := c.Delete(ctx, path); err != nil {
+				t.Fatalf("DELETE: %s", err)
+			}
+			if srv.Exists(path) {
+				t.Fatalf("%s not deleted", path)
+			}
+			if err := c.Delete(ctx, path); !restconf.IsNotFound(err) {
+				t.Fatalf("expected not found for a second delete, got %v", err)
+			}
+			if err := c.Patch(ctx, path, []byte(`{"Cisco-IOS-XE-native:definition": {"description": "foo"}}`)); err == nil {
+				t.Fatalf("expected PATCH of a deleted entry to fail")
+			}
+
+			commits := 0
+			for _, op := range srv.Requests() {
+				if op == "commit" {
+					commits++
+				}
+			}
+			if candidate && commits != 3 {
+				t.Fatalf("expected a commit per write, got %d: %v", commits, srv.Requests())
+			}
+			if !candidate && commits != 0 {
+				t.Fatalf("expected no commit without candidate, got %v", srv.Requests())
+			}
+		})
+	}
+}
+
+func TestTransport_listKeysFromDevice(t *testing.T) {
+	srv := netconftest.NewServer(false)
+	defer srv.Close()
+	c := testClient(srv)
+	ctx := context.Background()
+
+	err := srv.Merge(`<native xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-native"><ntp><server xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-ntp"><server-list><ip-address>192.0.2.1</ip-address><prefer/></server-list></server></ntp></native>`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	path := "Cisco-IOS-XE-native:native/ntp/Cisco-IOS-XE-ntp:server/server-list=192.0.2.1"
+
+	got := testGet(t, c, path)
+	want := map[string]interface{}{
+		"Cisco-IOS-XE-ntp:server-list": map[string]interface{}{"ip-address": "192.0.2.1", "prefer": []interface{}{nil}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	if err := c.Delete(ctx, path); err != nil {
+		t.Fatalf("DELETE: %s", err)
+	}
+	if srv.Exists(path) {
+		t.Fatalf("%s not deleted", path)
+	}
+}
+
+func TestTransport_commitFailure(t *testing.T) {
+	srv := netconftest.NewServer(true)
+	defer srv.Close()
+	c := testClient(srv)
+	path := "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR"
+
+	srv.Fail("commit", errors.New("commit failed"))
+	err := c.Put(context.Background(), path, []byte(`{"Cisco-IOS-XE-native:definition": {"name": "FOOBAR"}}`))
+	if err == nil || !strings.Contains(err.Error(), "commit failed") {
+		t.Fatalf("expected the commit error, got %v", err)
+	}
+	if srv.Exists(path) {
+		t.Fatalf("%s committed", path)
+	}
+
+	ops := srv.Requests()
+	want := []string{"get-config", "lock", "edit-config", "commit", "discard-changes", "unlock"}
+	if !reflect.DeepEqual(ops, want) {
+		t.Fatalf("expected operations %v, got %v", want, ops)
+	}
+}
+
+func TestTransport_confirmedCommit(t *testing.T) {
+	srv := netconftest.NewServer(true)
+	defer srv.Close()
+	tr := &netconf.Transport{HostKeyCallback: ssh.InsecureIgnoreHostKey(), ConfirmedCommit: true}
+	c := testClientTransport(srv, tr)
+	ctx := context.Background()
+	put := func(name string) {
+		path := "Cisco-IOS-XE-native:native/vrf/definition=" + name
+		if err := c.Put(ctx, path, []byte(fmt.Sprintf(`{"Cisco-IOS-XE-native:definition": {"name": %q}}`, name))); err != nil {
+			t.Fatalf("PUT %s: %s", path, err)
+		}
+	}
+
+	put("A")
+	if srv.Exists("Cisco-IOS-XE-native:native/vrf/definition=A") {
+		t.Fatalf("write committed before CommitConfirmed")
+	}
+	testGet(t, c, "Cisco-IOS-XE-native:native/vrf/definition=A")
+	if err := tr.CommitConfirmed(ctx, time.Minute); err != nil {
+		t.Fatalf("CommitConfirmed: %s", err)
+	}
+	if err := tr.Confirm(ctx); err != nil {
+		t.Fatalf("Confirm: %s", err)
+	}
+	tr.CloseIdleConnections()
+	if !srv.Exists("Cisco-IOS-XE-native:native/vrf/definition=A") {
+		t.Fatalf("confirmed commit reverted: %s", srv.Running())
+	}
+
+	put("B")
+	if err := tr.CommitConfirmed(ctx, time.Minute); err != nil {
+		t.Fatalf("CommitConfirmed: %s", err)
+	}
+	if !srv.Exists("Cisco-IOS-XE-native:native/vrf/definition=B") {
+		t.Fatalf("confirmed commit not applied: %s", srv.Running())
+	}
+	tr.Cancel()
+	if !waitGone(srv, "Cisco-IOS-XE-native:native/vrf/definition=B") {
+		t.Fatalf("cancelled commit not reverted: %s", srv.Running())
+	}
+
+	// the device becoming unreachable after the commit reverts it
+	srv.DropAfter("commit")
+	put("C")
+	if err := tr.CommitConfirmed(ctx, time.Minute); err != nil {
+		t.Fatalf("CommitConfirmed: %s", err)
+	}
+	if _, err := c.Get(ctx, "Cisco-IOS-XE-native:native/vrf/definition=C", nil); err == nil {
+		t.Fatalf("expected reads to fail once the session is lost")
+	}
+	if err := tr.Confirm(ctx); err == nil {
+		t.Fatalf("expected Confirm to fail once the session is lost")
+	}
+	if !waitGone(srv, "Cisco-IOS-XE-native:native/vrf/definition=C") {
+		t.Fatalf("commit of a lost session not reverted: %s", srv.Running())
+	}
+	if !srv.Exists("Cisco-IOS-XE-native:native/vrf/definition=A") {
+		t.Fatalf("earlier commit reverted: %s", srv.Running())
+	}
+}
+
+// waitGone waits for the server to process the end of a session removing the
+// element at path.
+func waitGone(srv *netconftest.Server, path string) bool {
+	for i := 0; i < 100; i++ {
+		if !srv.Exists(path) {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestTransport_rpc(t *testing.T) {
+	srv := netconftest.NewServer(false)
+	defer srv.Close()
+	c := testClient(srv)
+
+	srv.HandleRPC("cisco-ia:save-config", func(op *netconf.Node) ([]*netconf.Node, error) {
+		n := netconf.NewNode("http://cisco.com/yang/cisco-ia", "result")
+		n.Text = "Save running config successful"
+		return []*netconf.Node{n}, nil
+	})
+
+	b, err := c.Post(context.Background(), "cisco-ia:save-config", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(b) != `{"cisco-ia:output":{"result":"Save running config successful"}}` {
+		t.Fatalf("unexpected output %s", b)
+	}
+
+	if _, err := c.Post(context.Background(), "cisco-ia:rollback", nil); err == nil {
+		t.Fatalf("expected unknown operation to fail")
+	}
+}
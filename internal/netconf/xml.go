@@ -0,0 +1,176 @@
+package netconf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// BaseNamespace is the namespace of the NETCONF protocol elements.
+const BaseNamespace = "urn:ietf:params:xml:ns:netconf:base:1.0"
+
+// Node is an XML element. Names carry the namespace URI, not the prefix.
+type Node struct {
+	Name     xml.Name
+	Attrs    []xml.Attr
+	Children []*Node
+	Text     string
+}
+
+// NewNode returns an element named local in namespace space.
+func NewNode(space, local string, children ...*Node) *Node {
+	return &Node{Name: xml.Name{Space: space, Local: local}, Children: children}
+}
+
+// Child returns the first child element named local, in any namespace.
+func (n *Node) Child(local string) *Node {
+	for _, c := range n.Children {
+		if c.Name.Local == local {
+			return c
+		}
+	}
+	return nil
+}
+
+// ChildrenNamed returns the child elements named local, in any namespace.
+func (n *Node) ChildrenNamed(local string) []*Node {
+	r := []*Node{}
+	for _, c := range n.Children {
+		if c.Name.Local == local {
+			r = append(r, c)
+		}
+	}
+	return r
+}
+
+// Attr returns the value of the attribute named local in namespace space.
+func (n *Node) Attr(space, local string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// SetAttr sets the attribute named local in namespace space.
+func (n *Node) SetAttr(space, local, value string) {
+	for i, a := range n.Attrs {
+		if a.Name.Space == space && a.Name.Local == local {
+			n.Attrs[i].Value = value
+			return
+		}
+	}
+	n.Attrs = append(n.Attrs, xml.Attr{Name: xml.Name{Space: space, Local: local}, Value: value})
+}
+
+// IsLeaf reports whether n has no child elements.
+func (n *Node) IsLeaf() bool {
+	return len(n.Children) == 0
+}
+
+// Copy returns a deep copy of n.
+func (n *Node) Copy() *Node {
+	c := &Node{Name: n.Name, Text: n.Text}
+	c.Attrs = append([]xml.Attr(nil), n.Attrs...)
+	for _, ch := range n.Children {
+		c.Children = append(c.Children, ch.Copy())
+	}
+	return c
+}
+
+// ParseXML parses a document into its root element. Text is only kept for
+// elements without child elements.
+func ParseXML(b []byte) (*Node, error) {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+
+	var root *Node
+	stack := []*Node{}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &Node{Name: t.Name}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+					continue
+				}
+				n.Attrs = append(n.Attrs, a)
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, n)
+			} else if root == nil {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			n := stack[len(stack)-1]
+			if !n.IsLeaf() {
+				n.Text = ""
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no XML element found")
+	}
+
+	return root, nil
+}
+
+// String encodes n, declaring namespaces only where they change.
+func (n *Node) String() string {
+	var b strings.Builder
+	n.write(&b, "")
+	return b.String()
+}
+
+func (n *Node) write(b *strings.Builder, ns string) {
+	b.WriteString("<")
+	b.WriteString(n.Name.Local)
+	if n.Name.Space != ns {
+		b.WriteString(` xmlns="`)
+		xml.EscapeText(b, []byte(n.Name.Space))
+		b.WriteString(`"`)
+	}
+	for i, a := range n.Attrs {
+		if a.Name.Space == "" {
+			fmt.Fprintf(b, " %s=\"", a.Name.Local)
+		} else {
+			fmt.Fprintf(b, " xmlns:a%d=\"", i)
+			xml.EscapeText(b, []byte(a.Name.Space))
+			fmt.Fprintf(b, "\" a%d:%s=\"", i, a.Name.Local)
+		}
+		xml.EscapeText(b, []byte(a.Value))
+		b.WriteString(`"`)
+	}
+
+	if n.IsLeaf() && n.Text == "" {
+		b.WriteString("/>")
+		return
+	}
+	b.WriteString(">")
+	if n.IsLeaf() {
+		xml.EscapeText(b, []byte(n.Text))
+	}
+	for _, c := range n.Children {
+		c.write(b, n.Name.Space)
+	}
+	b.WriteString("</")
+	b.WriteString(n.Name.Local)
+	b.WriteString(">")
+}
@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/poroping/go-ios-xe-sdk/models"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
+)
+
+// bgpAddressFamily is the address-family list entry holding an address family
+// container, e.g. ipv4=unicast for ipv4-unicast.
+type bgpAddressFamily struct {
+	afi  string
+	safi string
+}
+
+// bgpAddressFamilies maps the address families, named after their container,
+// to their address-family list entry.
+var bgpAddressFamilies = map[string]bgpAddressFamily{
+	"ipv4-unicast":  {"ipv4", "unicast"},
+	"ipv6-unicast":  {"ipv6", "unicast"},
+	"vpnv4-unicast": {"vpnv4", "unicast"},
+	"vpnv6-unicast": {"vpnv6", "unicast"},
+	"l2vpn-evpn":    {"l2vpn", "evpn"},
+}
+
+// bgpVRFAddressFamilies are the address families configurable per VRF.
+var bgpVRFAddressFamilies = []string{"ipv4-unicast", "ipv6-unicast"}
+
+func bgpAddressFamilyNames() []string {
+	names := []string{}
+	for name := range bgpAddressFamilies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// bgpAddressFamilyPath returns the path of the container of the address
+// family af in vrf, the global table if vrf is empty.
+func bgpAddressFamilyPath(as int, vrf string, af string) string {
+	f := bgpAddressFamilies[af]
+	if vrf == "" {
+		return fmt.Sprintf("%s/address-family/no-vrf/%s=%s/%s", models.BgpPath(as), f.afi, f.safi, af)
+	}
+
+	return fmt.Sprintf("%s/address-family/with-vrf/%s=%s/vrf=%s/%s", models.BgpPath(as), f.afi, f.safi, url.PathEscape(vrf), af)
+}
+
+func bgpNeighborAddressFamilyPath(as int, vrf string, af string, ip string) string {
+	return fmt.Sprintf("%s/neighbor=%s", bgpAddressFamilyPath(as, vrf, af), ip)
+}
+
+// bgpAddressFamilyBody returns the body of a PATCH of router bgp as merging
+// content into the container of the address family af in vrf. The
+// address-family and vrf entries are created along the way, which a PUT of
+// the container can't do.
+func bgpAddressFamilyBody(as int, vrf string, af string, content map[string]interface{}) ([]byte, error) {
+	f := bgpAddressFamilies[af]
+	entry := map[string]interface{}{"af-name": f.safi}
+	table := "no-vrf"
+	if vrf == "" {
+		entry[af] = content
+	} else {
+		table = "with-vrf"
+		entry["vrf"] = []interface{}{map[string]interface{}{"name": vrf, af: content}}
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"Cisco-IOS-XE-bgp:bgp": map[string]interface{}{
+			"id": as,
+			"address-family": map[string]interface{}{
+				table: map[string]interface{}{
+					f.afi: []interface{}{entry},
+				},
+			},
+		},
+	})
+}
+
+// bgpDataPath returns path, a go-ios-xe-sdk models path, relative to the
+// RESTCONF data root.
+func bgpDataPath(path string) string {
+	return strings.TrimPrefix(path, restconf.DataPath)
+}
+
+// patchBgp merges body into router bgp as.
+func patchBgp(ctx context.Context, c *restconf.Client, as int, body []byte) error {
+	return c.Patch(ctx, bgpDataPath(models.BgpPath(as)), body)
+}
+
+// readBgp returns the body of a GET of path below router bgp, or a
+// NotFoundError if there is no data.
+func readBgp(ctx context.Context, c *restconf.Client, path string) ([]byte, error) {
+	return c.Get(ctx, bgpDataPath(path), nil)
+}
+
+// putBgp replaces the node at path below router bgp with body.
+func putBgp(ctx context.Context, c *restconf.Client, path string, body []byte) error {
+	return c.Put(ctx, bgpDataPath(path), body)
+}
+
+// deleteBgp deletes the node at path below router bgp, a node already absent
+// is ignored.
+func deleteBgp(ctx context.Context, c *restconf.Client, path string) error {
+	err := c.Delete(ctx, bgpDataPath(path))
+	if restconf.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// deleteBgpLeaves deletes each leaf below path, leaves already absent are
+// ignored.
+func deleteBgpLeaves(ctx context.Context, c *restconf.Client, path string, leaves []string) error {
+	for _, leaf := range leaves {
+		err := deleteBgp(ctx, c, fmt.Sprintf("%s/%s", path, leaf))
+		if err != nil {
+			return fmt.Errorf("unable to delete %s. %s", leaf, err)
+		}
+	}
+
+	return nil
+}
+
+// jsonContent returns the content of the single top level member of v encoded
+// as JSON, e.g. the neighbor entry of a models.BgpNeighbor.
+func jsonContent(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]map[string]interface{}{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	for _, content := range m {
+		return content, nil
+	}
+
+	return map[string]interface{}{}, nil
+}
+
+// bgpEntry returns the list entry of the body of a GET of a list entry, which
+// devices send as an object or as an array of one entry. Numbers are kept as
+// json.Number.
+func bgpEntry(body []byte) (map[string]interface{}, error) {
+	v, err := decodeJSON(body)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := v.(map[string]interface{})
+	for _, content := range m {
+		if l, ok := content.([]interface{}); ok && len(l) > 0 {
+			content = l[0]
+		}
+		if entry, ok := content.(map[string]interface{}); ok {
+			return entry, nil
+		}
+	}
+
+	return map[string]interface{}{}, nil
+}
+
+// jsonObject returns the container name of m, nil if m has none.
+func jsonObject(m map[string]interface{}, name string) map[string]interface{} {
+	v, _ := jsonMember(m, name)
+	o, _ := v.(map[string]interface{})
+	return o
+}
+
+func intValue(v interface{}) int {
+	i, _ := strconv.Atoi(stringValue(v))
+	return i
+}
+
+var interfaceNameRegexp = regexp.MustCompile(`^([A-Za-z][A-Za-z-]*)([0-9][0-9/.:]*)$`)
+
+// expandInterfaceName returns the choice of interface for name, e.g.
+// {"Loopback": 0} for Loopback0, as used by update-source. The member is
+// named after the interface type, numbered types take a number.
+func expandInterfaceName(name string) (map[string]interface{}, error) {
+	m := interfaceNameRegexp.FindStringSubmatch(name)
+	if m == nil {
+		return nil, fmt.Errorf("unexpected interface name %q, expected a type followed by its number, e.g. Loopback0", name)
+	}
+	if i, err := strconv.Atoi(m[2]); err == nil {
+		return map[string]interface{}{m[1]: i}, nil
+	}
+
+	return map[string]interface{}{m[1]: m[2]}, nil
+}
+
+// flattenInterfaceName returns the interface name of a choice of interface
+// built by expandInterfaceName, empty if there is none.
+func flattenInterfaceName(m map[string]interface{}) string {
+	for typ, number := range m {
+		return localJSONName(typ) + stringValue(number)
+	}
+
+	return ""
+}
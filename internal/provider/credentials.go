@@ -0,0 +1,204 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v2"
+)
+
+// defaultCredentialsFile holds the profiles selected with credentials_profile.
+const defaultCredentialsFile = "~/.iosxe/credentials"
+
+type credentials struct {
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	// Token is sent as bearer token instead of the username and password
+	Token string `json:"token" yaml:"token"`
+}
+
+// resolveCredentials returns the username and password, or the token, of the
+// provider. Each is taken from the first source setting it: the username,
+// password and token arguments, password_file, credentials_command, then the
+// credentials_profile of the credentials file. Sources are only read while
+// something is unset.
+func resolveCredentials(ctx context.Context, d *schema.ResourceData) (credentials, error) {
+	c := credentials{
+		Username: d.Get("username").(string),
+		Password: d.Get("password").(string),
+		Token:    d.Get("token").(string),
+	}
+
+	if f := d.Get("password_file").(string); f != "" && c.Password == "" && c.Token == "" {
+		b, err := os.ReadFile(expandHome(f))
+		if err != nil {
+			return c, fmt.Errorf("unable to read password_file. %s", err)
+		}
+		c.Password = strings.TrimRight(string(b), "\r\n")
+	}
+
+	if cmd := d.Get("credentials_command").(string); cmd != "" && !c.complete() {
+		found, err := credentialsFromCommand(ctx, cmd)
+		if err != nil {
+			return c, fmt.Errorf("error running credentials_command. %s", err)
+		}
+		c.fill(found)
+	}
+
+	if profile := d.Get("credentials_profile").(string); profile != "" && !c.complete() {
+		path := d.Get("credentials_file").(string)
+		found, err := credentialsFromProfile(expandHome(path), profile)
+		if err != nil {
+			return c, fmt.Errorf("unable to load credentials_profile %q from %s. %s", profile, path, err)
+		}
+		c.fill(found)
+	}
+
+	return c, nil
+}
+
+func (c *credentials) complete() bool {
+	return c.Token != "" || c.Username != "" && c.Password != ""
+}
+
+// fill sets what is unset in c from other.
+func (c *credentials) fill(other credentials) {
+	if c.Username == "" {
+		c.Username = other.Username
+	}
+	if c.Password == "" {
+		c.Password = other.Password
+	}
+	if c.Token == "" {
+		c.Token = other.Token
+	}
+}
+
+// credentialsFromCommand runs command, split on whitespace and without a
+// shell, and decodes the JSON object it prints.
+func credentialsFromCommand(ctx context.Context, command string) (credentials, error) {
+	c := credentials{}
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return c, fmt.Errorf("empty command")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return c, fmt.Errorf("%s %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), &c); err != nil {
+		return c, fmt.Errorf("expected a JSON object with username and password or token. %s", err)
+	}
+
+	return c, nil
+}
+
+// credentialsFromProfile returns the credentials of profile in the file at
+// path, YAML with a mapping per profile or INI with a section per profile.
+func credentialsFromProfile(path, profile string) (credentials, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return credentials{}, err
+	}
+
+	profiles := map[string]credentials{}
+	if isINI(path, b) {
+		profiles, err = parseINICredentials(b)
+	} else {
+		err = yaml.UnmarshalStrict(b, &profiles)
+	}
+	if err != nil {
+		return credentials{}, err
+	}
+
+	c, ok := profiles[profile]
+	if !ok {
+		return c, fmt.Errorf("no such profile")
+	}
+
+	return c, nil
+}
+
+// isINI reports whether the credentials file at path is in INI format, going
+// by its extension or else its first section header.
+func isINI(path string, b []byte) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ini":
+		return true
+	case ".yaml", ".yml":
+		return false
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		return strings.HasPrefix(line, "[")
+	}
+	return false
+}
+
+// parseINICredentials parses "[profile]" sections of "key = value" lines.
+func parseINICredentials(b []byte) (map[string]credentials, error) {
+	profiles := map[string]credentials{}
+	profile := ""
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			profile = strings.TrimSpace(line[1 : len(line)-1])
+			profiles[profile] = credentials{}
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || profile == "" {
+			return nil, fmt.Errorf("line %d: expected key = value in a [profile] section", n)
+		}
+		c := profiles[profile]
+		switch key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]); key {
+		case "username":
+			c.Username = value
+		case "password":
+			c.Password = value
+		case "token":
+			c.Token = value
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %s", n, key)
+		}
+		profiles[profile] = c
+	}
+
+	return profiles, s.Err()
+}
+
+// expandHome replaces a leading ~ in path with the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
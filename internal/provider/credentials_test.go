@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const testCredentialsYAML = `
+default:
+  username: yaml-user
+  password: yaml-pass
+lab:
+  username: lab-user
+gateway:
+  token: yaml-token
+`
+
+const testCredentialsINI = `
+# profiles
+[default]
+username = ini-user
+password = ini-pass
+
+[lab]
+password = lab-pass
+
+[gateway]
+token = ini-token
+`
+
+func testCredentialsFiles(t *testing.T) (string, string, string, string) {
+	dir := t.TempDir()
+	write := func(name, content string, mode os.FileMode) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return path
+	}
+
+	return write("credentials", testCredentialsYAML, 0600),
+		write("credentials.ini", testCredentialsINI, 0600),
+		write("password", "file-pass\n", 0600),
+		write("helper", "#!/bin/sh\necho '{\"username\": \"cmd-user\", \"password\": \"cmd-pass\"}'\n", 0700)
+}
+
+func TestResolveCredentials(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the credentials command is a shell script")
+	}
+	for _, env := range []string{"USERNAME", "PASSWORD", "PASSWORD_FILE", "CREDENTIALS_COMMAND", "CREDENTIALS_PROFILE", "CREDENTIALS_FILE", "TOKEN"} {
+		t.Setenv("TF_IOSXE_"+env, "")
+	}
+	yamlFile, iniFile, passwordFile, helper := testCredentialsFiles(t)
+
+	cases := []struct {
+		name string
+		raw  map[string]interface{}
+		want credentials
+		err  string
+	}{
+		{
+			name: "arguments",
+			raw:  map[string]interface{}{"username": "u", "password": "p", "password_file": passwordFile, "credentials_command": helper},
+			want: credentials{"u", "p", ""},
+		},
+		{
+			name: "password file",
+			raw:  map[string]interface{}{"username": "u", "password_file": passwordFile, "credentials_command": helper},
+			want: credentials{"u", "file-pass", ""},
+		},
+		{
+			name: "command",
+			raw:  map[string]interface{}{"password_file": passwordFile, "credentials_command": helper, "credentials_profile": "default", "credentials_file": yamlFile},
+			want: credentials{"cmd-user", "file-pass", ""},
+		},
+		{
+			name: "yaml profile",
+			raw:  map[string]interface{}{"credentials_profile": "default", "credentials_file": yamlFile},
+			want: credentials{"yaml-user", "yaml-pass", ""},
+		},
+		{
+			name: "partial profile",
+			raw:  map[string]interface{}{"password": "p", "credentials_profile": "lab", "credentials_file": yamlFile},
+			want: credentials{"lab-user", "p", ""},
+		},
+		{
+			name: "ini profile",
+			raw:  map[string]interface{}{"username": "u", "credentials_profile": "lab", "credentials_file": iniFile},
+			want: credentials{"u", "lab-pass", ""},
+		},
+		{
+			name: "token",
+			raw:  map[string]interface{}{"token": "t", "password_file": passwordFile, "credentials_command": helper},
+			want: credentials{"", "", "t"},
+		},
+		{
+			name: "yaml token profile",
+			raw:  map[string]interface{}{"credentials_profile": "gateway", "credentials_file": yamlFile},
+			want: credentials{"", "", "yaml-token"},
+		},
+		{
+			name: "ini token profile",
+			raw:  map[string]interface{}{"credentials_profile": "gateway", "credentials_file": iniFile},
+			want: credentials{"", "", "ini-token"},
+		},
+		{
+			name: "unknown profile",
+			raw:  map[string]interface{}{"credentials_profile": "prod", "credentials_file": yamlFile},
+			err:  `credentials_profile "prod"`,
+		},
+		{
+			name: "missing password file",
+			raw:  map[string]interface{}{"password_file": passwordFile + ".missing"},
+			err:  "unable to read password_file",
+		},
+		{
+			name: "failing command",
+			raw:  map[string]interface{}{"credentials_command": "false"},
+			err:  "error running credentials_command",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, New("test")().Schema, c.raw)
+			got, err := resolveCredentials(context.Background(), d)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("expected error containing %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if got != c.want {
+				t.Fatalf("expected %+v, got %+v", c.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
+)
+
+const deviceHardwarePath = "Cisco-IOS-XE-device-hardware-oper:device-hardware-data/device-hardware"
+
+func dataSourceDevice() *schema.Resource {
+	return &schema.Resource{
+		Description: "Get facts about a device: hostname, version, platform, serial numbers, uptime, boot image and license level.",
+
+		ReadContext: dataSourceDeviceRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"device": deviceSchema(),
+			"hostname": {
+				Description: "Configured hostname.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"version": {
+				Description: "IOS-XE version of the config, e.g. `17.3`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"software_version": {
+				Description: "Full software version string.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"pid": {
+				Description: "Product ID of the chassis, of the first member of a stack.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"serial_numbers": {
+				Description: "Serial numbers of the chassis, one per member of a stack.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"boot_time": {
+				Description: "Time the device booted, in RFC 3339 format.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"uptime": {
+				Description: "Seconds since the device booted.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"boot_image": {
+				Description: "First image of the `boot system` config.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"license_level": {
+				Description: "Configured `license boot level`.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataSourceDeviceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	native := map[string]interface{}{}
+	for _, path := range []string{"hostname", "version", "license/boot/level", "boot/system"} {
+		v, err := getOptional(ctx, client, "Cisco-IOS-XE-native:native/"+path, nil)
+		if err != nil {
+			return diag.Errorf("error retrieving device facts. %s", err)
+		}
+		native[path] = v
+	}
+
+	hardware, err := getOptional(ctx, client, deviceHardwarePath, url.Values{"content": []string{"nonconfig"}})
+	if err != nil {
+		return diag.Errorf("error retrieving device facts. %s", err)
+	}
+
+	hostname := stringValue(native["hostname"])
+	d.Set("hostname", hostname)
+	d.Set("version", stringValue(native["version"]))
+	d.Set("license_level", licenseLevel(native["license/boot/level"]))
+	d.Set("boot_image", bootImage(native["boot/system"]))
+
+	pid, serials := chassisInventory(hardware)
+	d.Set("pid", pid)
+	d.Set("serial_numbers", serials)
+
+	system := map[string]interface{}{}
+	if m, ok := hardware.(map[string]interface{}); ok {
+		if v, ok := jsonMember(m, "device-system-data"); ok {
+			system, _ = v.(map[string]interface{})
+		}
+	}
+	software, _ := jsonMember(system, "software-version")
+	d.Set("software_version", stringValue(software))
+
+	bootTime, _ := jsonMember(system, "boot-time")
+	currentTime, _ := jsonMember(system, "current-time")
+	d.Set("boot_time", stringValue(bootTime))
+	d.Set("uptime", uptime(stringValue(bootTime), stringValue(currentTime)))
+
+	id := hostname
+	if id == "" {
+		id = client.Host
+	}
+	d.SetId(id)
+
+	return nil
+}
+
+// getOptional returns the value at path, nil if the device has no data there.
+func getOptional(ctx context.Context, client *restconf.Client, path string, query url.Values) (interface{}, error) {
+	b, err := client.Get(ctx, path, query)
+	if restconf.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s. %s", path, err)
+	}
+
+	v, err := restconfValue(b)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode %s. %s", path, err)
+	}
+
+	return v, nil
+}
+
+func stringValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// licenseLevel returns the level of the license/boot/level container, whose
+// single member is named after the level, e.g. network-advantage.
+func licenseLevel(v interface{}) string {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	levels := []string{}
+	for k := range m {
+		levels = append(levels, localJSONName(k))
+	}
+	if len(levels) == 0 {
+		return ""
+	}
+	sort.Strings(levels)
+
+	return levels[0]
+}
+
+// bootImage returns the first image of boot/system, listed in the order of the
+// config under flash or bootfile.
+func bootImage(v interface{}) string {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	for _, c := range []struct{ container, list, leaf string }{
+		{"flash", "flash-list-ordered-by-user", "flash-leaf"},
+		{"bootfile", "filename-list-ordered-by-user", "filename"},
+	} {
+		container, _ := jsonMember(m, c.container)
+		cm, _ := container.(map[string]interface{})
+		list, _ := jsonMember(cm, c.list)
+		entries, _ := list.([]interface{})
+		for _, e := range entries {
+			em, _ := e.(map[string]interface{})
+			if leaf, ok := jsonMember(em, c.leaf); ok {
+				return stringValue(leaf)
+			}
+		}
+	}
+
+	return ""
+}
+
+// chassisInventory returns the PID of the first chassis of the device-inventory
+// and the serial numbers of all of them, ordered by hw-dev-index.
+func chassisInventory(v interface{}) (string, []string) {
+	m, _ := v.(map[string]interface{})
+	list, _ := jsonMember(m, "device-inventory")
+	entries, _ := list.([]interface{})
+
+	chassis := []map[string]interface{}{}
+	for _, e := range entries {
+		em, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if t, _ := jsonMember(em, "hw-type"); localJSONName(stringValue(t)) == "hw-type-chassis" {
+			chassis = append(chassis, em)
+		}
+	}
+	sort.SliceStable(chassis, func(i, j int) bool {
+		a, _ := jsonMember(chassis[i], "hw-dev-index")
+		b, _ := jsonMember(chassis[j], "hw-dev-index")
+		x, _ := strconv.Atoi(stringValue(a))
+		y, _ := strconv.Atoi(stringValue(b))
+		return x < y
+	})
+
+	pid := ""
+	serials := []string{}
+	for _, c := range chassis {
+		if pid == "" {
+			p, _ := jsonMember(c, "part-number")
+			pid = stringValue(p)
+		}
+		if s, ok := jsonMember(c, "serial-number"); ok {
+			serials = append(serials, stringValue(s))
+		}
+	}
+
+	return pid, serials
+}
+
+// uptime returns the seconds between the RFC 3339 times boot and now, 0 if
+// either is missing.
+func uptime(boot string, now string) int {
+	b, err := time.Parse(time.RFC3339, boot)
+	if err != nil {
+		return 0
+	}
+	n, err := time.Parse(time.RFC3339, now)
+	if err != nil {
+		return 0
+	}
+
+	return int(n.Sub(b).Seconds())
+}
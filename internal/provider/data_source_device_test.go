@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestDeviceDataSource_mock(t *testing.T) {
+	srv := testAccMockDevice(t)
+	srv.Put("Cisco-IOS-XE-native:native/hostname", `{"Cisco-IOS-XE-native:hostname": "sw1"}`)
+	srv.Put("Cisco-IOS-XE-native:native/version", `{"Cisco-IOS-XE-native:version": "17.3"}`)
+	srv.Put("Cisco-IOS-XE-native:native/license", `{"Cisco-IOS-XE-native:license": {"boot": {"level": {"network-advantage": {"addon": "dna-advantage"}}}}}`)
+	srv.Put("Cisco-IOS-XE-native:native/boot", `{"Cisco-IOS-XE-native:boot": {"system": {"flash": {"flash-list-ordered-by-user": [{"flash-leaf": "flash:packages.conf"}]}}}}`)
+	srv.Put(deviceHardwarePath, `{"Cisco-IOS-XE-device-hardware-oper:device-hardware": {
+		"device-inventory": [
+			{"hw-type": "hw-type-chassis", "hw-dev-index": 2, "part-number": "C9300-48P", "serial-number": "FOC2"},
+			{"hw-type": "hw-type-psu", "hw-dev-index": 1, "part-number": "PWR-C1-715WAC", "serial-number": "LIT1"},
+			{"hw-type": "hw-type-chassis", "hw-dev-index": 1, "part-number": "C9300-24P", "serial-number": "FOC1"}
+		],
+		"device-system-data": {
+			"software-version": "Cisco IOS Software [Amsterdam], Catalyst L3 Switch Software (CAT9K_IOSXE), Version 17.3.4",
+			"boot-time": "2021-06-01T10:00:00+00:00",
+			"current-time": "2021-06-02T11:00:05+00:00"
+		}
+	}}`)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `data "iosxe_device" "example" {}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.iosxe_device.example", "id", "sw1"),
+					resource.TestCheckResourceAttr("data.iosxe_device.example", "hostname", "sw1"),
+					resource.TestCheckResourceAttr("data.iosxe_device.example", "version", "17.3"),
+					resource.TestCheckResourceAttr("data.iosxe_device.example", "pid", "C9300-24P"),
+					resource.TestCheckResourceAttr("data.iosxe_device.example", "serial_numbers.#", "2"),
+					resource.TestCheckResourceAttr("data.iosxe_device.example", "serial_numbers.0", "FOC1"),
+					resource.TestCheckResourceAttr("data.iosxe_device.example", "serial_numbers.1", "FOC2"),
+					resource.TestCheckResourceAttr("data.iosxe_device.example", "uptime", "90005"),
+					resource.TestCheckResourceAttr("data.iosxe_device.example", "boot_image", "flash:packages.conf"),
+					resource.TestCheckResourceAttr("data.iosxe_device.example", "license_level", "network-advantage"),
+				),
+			},
+		},
+	})
+}
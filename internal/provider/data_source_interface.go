@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/poroping/go-ios-xe-sdk/models"
+)
+
+const interfacesPath = "Cisco-IOS-XE-native:native/interface"
+
+// dataSourceInterfaceSchema returns the attributes the interface data sources
+// read, all computed.
+func dataSourceInterfaceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"description": {
+			Description: "Interface description.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+		"ip": {
+			Description: "Primary interface IP as CIDR.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+		"secondary_ip": {
+			Description: "Secondary IPs.",
+			Type:        schema.TypeList,
+			Computed:    true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"ip": {
+						Description: "Secondary interface IP as CIDR.",
+						Type:        schema.TypeString,
+						Computed:    true,
+					},
+				},
+			},
+		},
+		"shutdown": {
+			Description: "Interface status.",
+			Type:        schema.TypeBool,
+			Computed:    true,
+		},
+		"vrf": {
+			Description: "VRF.",
+			Type:        schema.TypeString,
+			Computed:    true,
+		},
+	}
+}
+
+func dataSourceInterface() *schema.Resource {
+	s := dataSourceInterfaceSchema()
+	s["device"] = deviceSchema()
+	s["type"] = &schema.Schema{
+		Description:  "Interface type, e.g. `GigabitEthernet`, `Loopback` or `Vlan`.",
+		Type:         schema.TypeString,
+		Required:     true,
+		ValidateFunc: validation.StringIsNotWhiteSpace,
+	}
+	s["name"] = &schema.Schema{
+		Description:  "Interface name without the type, e.g. `1/0/1`.",
+		Type:         schema.TypeString,
+		Required:     true,
+		ValidateFunc: validation.StringIsNotWhiteSpace,
+	}
+
+	return &schema.Resource{
+		Description: "Get an interface of any type.",
+
+		ReadContext: dataSourceInterfaceRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: s,
+	}
+}
+
+func dataSourceInterfaceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	typ := d.Get("type").(string)
+	name := d.Get("name").(string)
+
+	resp, err := client.Get(ctx, fmt.Sprintf("%s/%s=%s", interfacesPath, typ, url.PathEscape(name)), nil)
+
+	if err != nil {
+		return diag.Errorf("error retrieving %s%s. %s", typ, name, err)
+	}
+
+	body := map[string]models.Interface{}
+	if err := json.Unmarshal(resp, &body); err != nil {
+		return diag.Errorf("error decoding %s%s. %s", typ, name, err)
+	}
+	for _, v := range body {
+		for k, value := range flattenInterface(typ, &v) {
+			if k != "type" && k != "name" {
+				d.Set(k, value)
+			}
+		}
+	}
+
+	d.SetId(typ + name)
+
+	return nil
+}
+
+// flattenInterface returns the attributes of the interface resp of type typ.
+func flattenInterface(typ string, resp *models.Interface) map[string]interface{} {
+	m := map[string]interface{}{
+		"type":         typ,
+		"name":         resp.Name,
+		"description":  "",
+		"ip":           "",
+		"secondary_ip": []map[string]interface{}{},
+		"shutdown":     resp.Shutdown != nil,
+		"vrf":          "",
+	}
+	if resp.Description != nil {
+		m["description"] = *resp.Description
+	}
+	if resp.IP != nil && resp.IP.Address != nil {
+		if resp.IP.Address.Primary != nil {
+			resp.IP.Address.Primary.SetCIDR()
+			m["ip"] = resp.IP.Address.Primary.CIDR
+		}
+		m["secondary_ip"] = flattenPortChannelSecondaryIPs(resp.IP.Address.Secondary)
+	}
+	if resp.Vrf != nil && resp.Vrf.Forwarding != nil {
+		m["vrf"] = *resp.Vrf.Forwarding
+	}
+
+	return m
+}
@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/poroping/go-ios-xe-sdk/models"
+)
+
+func dataSourcePortChannel() *schema.Resource {
+	s := dataSourceInterfaceSchema()
+	s["device"] = deviceSchema()
+	s["name"] = &schema.Schema{
+		Description:  "Interface name.",
+		Type:         schema.TypeString,
+		Required:     true,
+		ValidateFunc: validation.StringIsNotWhiteSpace,
+	}
+
+	return &schema.Resource{
+		Description: "Get a PortChannel interface.",
+
+		ReadContext: dataSourcePortChannelRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: s,
+	}
+}
+
+func dataSourcePortChannelRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id := d.Get("name").(string)
+
+	params := models.PortChannel{}
+	params.PortChannel.Name = id
+	resp, err := client.ReadPortChannel(params)
+
+	if err != nil {
+		return diag.Errorf("error retrieving PortChannel. %s", err)
+	}
+
+	resourceSetPortChannel(d, &resp.PortChannel)
+
+	d.SetId(id)
+
+	return nil
+}
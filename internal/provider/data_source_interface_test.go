@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf/restconftest"
+)
+
+func testAccMockInterfaces(t *testing.T) *restconftest.Server {
+	srv := testAccMockDevice(t)
+	srv.Put(interfacesPath, `{"Cisco-IOS-XE-native:interface": {
+		"GigabitEthernet": [
+			{"name": "1/0/10", "description": "uplink core2", "vrf": {"forwarding": "MGMT"}},
+			{"name": "1/0/2", "description": "uplink core1", "ip": {"address": {"primary": {"address": "10.0.0.1", "mask": "255.255.255.252"}}}},
+			{"name": "1/0/3", "shutdown": [null]}
+		],
+		"Vlan": [
+			{"name": 666, "description": "users", "ip": {"address": {"primary": {"address": "10.6.6.1", "mask": "255.255.255.0"}, "secondary": [{"address": "10.6.7.1", "mask": "255.255.255.0", "secondary": [null]}]}}, "vrf": {"forwarding": "FOOBAR"}}
+		],
+		"Port-channel": [
+			{"name": 69, "description": "to access", "shutdown": [null]}
+		],
+		"Port-channel-subinterface": {"Port-channel": [
+			{"name": "69.420", "vrf": {"forwarding": "FOOBAR"}}
+		]}
+	}}`)
+
+	return srv
+}
+
+func TestInterfaceDataSource_mock(t *testing.T) {
+	testAccMockInterfaces(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInterfaceDataSourceConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.iosxe_interface.example", "description", "uplink core1"),
+					resource.TestCheckResourceAttr("data.iosxe_interface.example", "ip", "10.0.0.1/30"),
+					resource.TestCheckResourceAttr("data.iosxe_interface.example", "shutdown", "false"),
+					resource.TestCheckResourceAttr("data.iosxe_interface_vlan.example", "name", "Vlan666"),
+					resource.TestCheckResourceAttr("data.iosxe_interface_vlan.example", "ip", "10.6.6.1/24"),
+					resource.TestCheckResourceAttr("data.iosxe_interface_vlan.example", "secondary_ip.0.ip", "10.6.7.1/24"),
+					resource.TestCheckResourceAttr("data.iosxe_interface_vlan.example", "vrf", "FOOBAR"),
+					resource.TestCheckResourceAttr("data.iosxe_interface_port_channel.example", "description", "to access"),
+					resource.TestCheckResourceAttr("data.iosxe_interface_port_channel.example", "shutdown", "true"),
+				),
+			},
+		},
+	})
+}
+
+const testAccInterfaceDataSourceConfig = `
+data "iosxe_interface" "example" {
+  type = "GigabitEthernet"
+  name = "1/0/2"
+}
+
+data "iosxe_interface_vlan" "example" {
+  vlanid = 666
+}
+
+data "iosxe_interface_port_channel" "example" {
+  name = "69"
+}
+`
+
+func TestInterfacesDataSource_mock(t *testing.T) {
+	testAccMockInterfaces(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInterfacesDataSourceConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.all", "interfaces.#", "6"),
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.all", "interfaces.0.name", "1/0/2"),
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.all", "interfaces.1.name", "1/0/3"),
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.all", "interfaces.2.name", "1/0/10"),
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.all", "interfaces.3.type", "Port-channel"),
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.all", "interfaces.4.name", "69.420"),
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.all", "interfaces.5.type", "Vlan"),
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.gig", "interfaces.#", "3"),
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.foobar", "interfaces.#", "2"),
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.foobar", "interfaces.0.name", "69.420"),
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.foobar", "interfaces.1.secondary_ip.0.ip", "10.6.7.1/24"),
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.uplinks", "interfaces.#", "2"),
+					resource.TestCheckResourceAttr("data.iosxe_interfaces.uplinks", "interfaces.1.vrf", "MGMT"),
+				),
+			},
+		},
+	})
+}
+
+const testAccInterfacesDataSourceConfig = `
+data "iosxe_interfaces" "all" {}
+
+data "iosxe_interfaces" "gig" {
+  type = "GigabitEthernet"
+}
+
+data "iosxe_interfaces" "foobar" {
+  vrf = "FOOBAR"
+}
+
+data "iosxe_interfaces" "uplinks" {
+  type              = "GigabitEthernet"
+  description_regex = "^uplink"
+}
+`
@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/poroping/go-ios-xe-sdk/models"
+)
+
+func dataSourceVlan() *schema.Resource {
+	s := dataSourceInterfaceSchema()
+	s["device"] = deviceSchema()
+	s["name"] = &schema.Schema{
+		Description: "Interface name.",
+		Type:        schema.TypeString,
+		Computed:    true,
+	}
+	s["vlanid"] = &schema.Schema{
+		Description:  "VLANID.",
+		Type:         schema.TypeInt,
+		Required:     true,
+		ValidateFunc: validation.IntBetween(1, 4096),
+	}
+
+	return &schema.Resource{
+		Description: "Get a Vlan interface.",
+
+		ReadContext: dataSourceVlanRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: s,
+	}
+}
+
+func dataSourceVlanRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id := d.Get("vlanid").(int)
+
+	params := models.Vlan{}
+	params.Vlan.Name = strconv.Itoa(id)
+	resp, err := client.ReadVlan(params)
+
+	if err != nil {
+		return diag.Errorf("error retrieving Vlan. %s", err)
+	}
+
+	resourceSetVlan(d, &resp.Vlan)
+
+	d.SetId(strconv.Itoa(id))
+
+	return nil
+}
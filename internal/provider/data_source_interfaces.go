@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/poroping/go-ios-xe-sdk/models"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
+)
+
+func dataSourceInterfaces() *schema.Resource {
+	s := dataSourceInterfaceSchema()
+	s["type"] = &schema.Schema{
+		Description: "Interface type, e.g. `GigabitEthernet`.",
+		Type:        schema.TypeString,
+		Computed:    true,
+	}
+	s["name"] = &schema.Schema{
+		Description: "Interface name without the type, e.g. `1/0/1`.",
+		Type:        schema.TypeString,
+		Computed:    true,
+	}
+
+	return &schema.Resource{
+		Description: "List the interfaces of a device, optionally filtered by type, VRF or description.",
+
+		ReadContext: dataSourceInterfacesRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"device": deviceSchema(),
+			"type": {
+				Description: "Only list interfaces of this type, e.g. `GigabitEthernet`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"vrf": {
+				Description: "Only list interfaces in this VRF.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"description_regex": {
+				Description:  "Only list interfaces whose description matches this regular expression.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"interfaces": {
+				Description: "Interfaces, ordered by type and name.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Resource{Schema: s},
+			},
+		},
+	}
+}
+
+func dataSourceInterfacesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	resp, err := client.Get(ctx, interfacesPath, nil)
+
+	if err != nil && !restconf.IsNotFound(err) {
+		return diag.Errorf("error retrieving interfaces. %s", err)
+	}
+
+	interfaces := []map[string]interface{}{}
+	if err == nil {
+		body := map[string]map[string]json.RawMessage{}
+		if err := json.Unmarshal(resp, &body); err != nil {
+			return diag.Errorf("error decoding interfaces. %s", err)
+		}
+		for _, types := range body {
+			interfaces = flattenInterfaces(types)
+		}
+	}
+
+	var descriptionRegex *regexp.Regexp
+	if v, ok := d.GetOk("description_regex"); ok {
+		descriptionRegex = regexp.MustCompile(v.(string))
+	}
+	filtered := []map[string]interface{}{}
+	for _, m := range interfaces {
+		if v, ok := d.GetOk("type"); ok && m["type"] != v {
+			continue
+		}
+		if v, ok := d.GetOk("vrf"); ok && m["vrf"] != v {
+			continue
+		}
+		if descriptionRegex != nil && !descriptionRegex.MatchString(m["description"].(string)) {
+			continue
+		}
+		filtered = append(filtered, m)
+	}
+
+	d.Set("interfaces", filtered)
+
+	d.SetId(client.Host)
+
+	return nil
+}
+
+// flattenInterfaces returns the interfaces of the interface container, whose
+// members are the lists of each type, sorted by type and name. Subinterfaces
+// are nested a level deeper, e.g. Port-channel-subinterface/Port-channel, and
+// are listed with the type of their parent.
+func flattenInterfaces(types map[string]json.RawMessage) []map[string]interface{} {
+	interfaces := []map[string]interface{}{}
+	for typ, raw := range types {
+		list := []models.Interface{}
+		if err := json.Unmarshal(raw, &list); err == nil {
+			for i := range list {
+				interfaces = append(interfaces, flattenInterface(localJSONName(typ), &list[i]))
+			}
+			continue
+		}
+		nested := map[string]json.RawMessage{}
+		if err := json.Unmarshal(raw, &nested); err == nil {
+			interfaces = append(interfaces, flattenInterfaces(nested)...)
+		}
+	}
+
+	sort.SliceStable(interfaces, func(i, j int) bool {
+		if interfaces[i]["type"] != interfaces[j]["type"] {
+			return interfaces[i]["type"].(string) < interfaces[j]["type"].(string)
+		}
+		return lessInterfaceName(interfaces[i]["name"].(string), interfaces[j]["name"].(string))
+	})
+
+	return interfaces
+}
+
+// lessInterfaceName orders interface names like 1/0/2 before 1/0/10.
+func lessInterfaceName(a, b string) bool {
+	sep := func(r rune) bool { return r == '/' || r == '.' || r == ':' }
+	as, bs := strings.FieldsFunc(a, sep), strings.FieldsFunc(b, sep)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, errx := strconv.Atoi(as[i])
+		y, erry := strconv.Atoi(bs[i])
+		if errx != nil || erry != nil {
+			if as[i] != bs[i] {
+				return as[i] < bs[i]
+			}
+			continue
+		}
+		if x != y {
+			return x < y
+		}
+	}
+	return len(as) < len(bs)
+}
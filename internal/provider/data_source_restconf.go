@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceRestconf() *schema.Resource {
+	return &schema.Resource{
+		Description: "Read config or operational data at an arbitrary RESTCONF path.",
+
+		ReadContext: dataSourceRestconfRead,
+
+		Timeouts: &schema.ResourceTimeout{
+			Read: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"device": deviceSchema(),
+			"path": {
+				Description:  "Path below `/restconf/data`, e.g. `Cisco-IOS-XE-native:native/hostname` or `Cisco-IOS-XE-interfaces-oper:interfaces`.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+			},
+			"depth": {
+				Description:  "Number of levels of child nodes returned.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"fields": {
+				Description: "RESTCONF `fields` expression selecting the child nodes returned, e.g. `name;description`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"content": {
+				Description:  "Return `config` data only, `nonconfig` data only or `all`.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"config", "nonconfig", "all"}, false),
+			},
+			"json": {
+				Description: "Response as JSON, without the top level member.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"attributes": {
+				Description: "Response flattened to a map of leaf values, keyed by the dotted path of the leaf in local names with list indexes, starting with the node at `path`, e.g. `ntp.server.server-list.0.ip-address`.",
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceRestconfRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	path := d.Get("path").(string)
+
+	query := url.Values{}
+	if v, ok := d.GetOk("depth"); ok {
+		query.Set("depth", strconv.Itoa(v.(int)))
+	}
+	if v, ok := d.GetOk("fields"); ok {
+		query.Set("fields", v.(string))
+	}
+	if v, ok := d.GetOk("content"); ok {
+		query.Set("content", v.(string))
+	}
+
+	resp, err := client.Get(ctx, path, query)
+
+	if err != nil {
+		return diag.Errorf("error retrieving %s. %s", path, err)
+	}
+
+	err = dataSetRestconf(d, resp)
+	if err != nil {
+		return diag.Errorf("error decoding %s. %s", path, err)
+	}
+
+	d.SetId(path)
+
+	return nil
+}
+
+func dataSetRestconf(d *schema.ResourceData, resp []byte) error {
+	value, err := restconfValue(resp)
+	if err != nil {
+		return err
+	}
+	j, err := encodeJSON(value)
+	if err != nil {
+		return err
+	}
+
+	// keep the top level member so a single leaf is keyed by its name
+	body, _ := decodeJSON(resp)
+	attributes := map[string]string{}
+	flattenJSON(body, "", attributes)
+
+	d.Set("json", j)
+	d.Set("attributes", attributes)
+
+	return nil
+}
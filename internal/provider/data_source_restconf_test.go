@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf/restconftest"
+)
+
+func TestRestconfDataSource_mock(t *testing.T) {
+	srv := testAccMockDevice(t)
+	srv.Put("Cisco-IOS-XE-native:native/vrf", `{"Cisco-IOS-XE-native:vrf": {"definition": [{"name": "FOOBAR", "rd": "566:4560", "address-family": {"ipv4": {}}}]}}`)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRestconfDataSourceConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.iosxe_restconf.example", "attributes.definition.name", "FOOBAR"),
+					resource.TestCheckResourceAttr("data.iosxe_restconf.example", "attributes.definition.rd", "566:4560"),
+					resource.TestCheckResourceAttr("data.iosxe_restconf.example", "json", `{"address-family":{"ipv4":{}},"name":"FOOBAR","rd":"566:4560"}`),
+					testAccCheckMockQuery(srv, "depth=2&fields=name%3Brd&content=config"),
+				),
+			},
+		},
+	})
+}
+
+const testAccRestconfDataSourceConfig = `
+data "iosxe_restconf" "example" {
+  path    = "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR"
+  depth   = 2
+  fields  = "name;rd"
+  content = "config"
+}
+`
+
+func testAccCheckMockQuery(srv *restconftest.Server, query string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		q, err := url.ParseQuery(query)
+		if err != nil {
+			return err
+		}
+		for _, r := range srv.Requests() {
+			if r.Method == "GET" && r.Query.Encode() == q.Encode() {
+				return nil
+			}
+		}
+		return fmt.Errorf("no GET with query %s received", query)
+	}
+}
+
+func TestRestconfDataSource_mockNotFound(t *testing.T) {
+	testAccMockDevice(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccRestconfDataSourceConfig,
+				ExpectError: regexp.MustCompile("no data found"),
+			},
+		},
+	})
+}
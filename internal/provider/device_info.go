@@ -0,0 +1,215 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
+)
+
+const (
+	yangLibraryPath = "ietf-yang-library:modules-state"
+	versionPath     = "Cisco-IOS-XE-native:native/version"
+)
+
+// resourceModules are the YANG modules resources need besides
+// Cisco-IOS-XE-native.
+var resourceModules = map[string][]string{
+	"iosxe_l2_vlan":        {"Cisco-IOS-XE-vlan"},
+	"iosxe_bgp_router":     {"Cisco-IOS-XE-bgp"},
+	"iosxe_bgp_neighbor":   {"Cisco-IOS-XE-bgp"},
+	"iosxe_bgp_peer_group": {"Cisco-IOS-XE-bgp"},
+	"iosxe_cli":            {"Cisco-IOS-XE-cli-rpc"},
+	"iosxe_save_config":    {"cisco-ia"},
+}
+
+// deviceInfo is what discovery found out about a device, empty fields are
+// unknown.
+type deviceInfo struct {
+	// version is the IOS-XE version of the config, e.g. "17.3"
+	version string
+	// modules maps the YANG modules the device implements to their revision
+	modules map[string]string
+}
+
+// discovery caches the deviceInfo of a device once it was discovered.
+type discovery struct {
+	mu   sync.Mutex
+	done bool
+	info deviceInfo
+}
+
+// deviceInfo returns what the device name supports, discovering it on first
+// use. Failed discoveries aren't cached, the next call tries again.
+func (c *apiClient) deviceInfo(ctx context.Context, name string) (deviceInfo, error) {
+	c.mu.Lock()
+	dv, ok := c.discoveries[name]
+	if !ok {
+		dv = &discovery{}
+		c.discoveries[name] = dv
+	}
+	c.mu.Unlock()
+
+	dv.mu.Lock()
+	defer dv.mu.Unlock()
+
+	if dv.done {
+		return dv.info, nil
+	}
+
+	client, err := c.deviceRestconfClient(name)
+	if err != nil {
+		return deviceInfo{}, err
+	}
+	info, err := discover(ctx, client)
+	if err != nil {
+		return deviceInfo{}, err
+	}
+	dv.info, dv.done = info, true
+
+	return info, nil
+}
+
+// discover reads the version from the native config and the implemented
+// modules from ietf-yang-library. Devices without either are not an error.
+func discover(ctx context.Context, client *restconf.Client) (deviceInfo, error) {
+	info := deviceInfo{}
+
+	b, err := client.Get(ctx, versionPath, nil)
+	if err != nil && !restconf.IsNotFound(err) {
+		return info, fmt.Errorf("unable to read the version. %s", err)
+	}
+	if err == nil {
+		v := map[string]interface{}{}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return info, fmt.Errorf("unable to decode the version. %s", err)
+		}
+		if version, ok := v["Cisco-IOS-XE-native:version"]; ok {
+			info.version = fmt.Sprint(version)
+		}
+	}
+
+	b, err = client.Get(ctx, yangLibraryPath, url.Values{"content": []string{"nonconfig"}})
+	if err != nil && !restconf.IsNotFound(err) {
+		return info, fmt.Errorf("unable to read the YANG library. %s", err)
+	}
+	if err == nil {
+		v := map[string]struct {
+			Module []struct {
+				Name     string `json:"name"`
+				Revision string `json:"revision"`
+			} `json:"module"`
+		}{}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return info, fmt.Errorf("unable to decode the YANG library. %s", err)
+		}
+		info.modules = map[string]string{}
+		for _, m := range v[yangLibraryPath].Module {
+			info.modules[m.Name] = m.Revision
+		}
+	}
+
+	return info, nil
+}
+
+// missingModules returns those of modules the device does not implement, none
+// if its modules are unknown.
+func (i deviceInfo) missingModules(modules []string) []string {
+	missing := []string{}
+	if i.modules == nil {
+		return missing
+	}
+	for _, m := range modules {
+		if _, ok := i.modules[m]; !ok {
+			missing = append(missing, m)
+		}
+	}
+	return missing
+}
+
+// atLeast reports whether the device runs version min or later, true if its
+// version is unknown.
+func (i deviceInfo) atLeast(min string) bool {
+	if i.version == "" {
+		return true
+	}
+	return compareVersions(i.version, min) >= 0
+}
+
+// compareVersions compares dotted versions like "16.12" and "17.3" by their
+// numeric components.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// planDeviceInfo returns the deviceInfo of the device the planned resource is
+// on. Discovery errors are logged, the plan isn't held up by them.
+func planDeviceInfo(ctx context.Context, d *schema.ResourceDiff, meta interface{}) (deviceInfo, bool) {
+	c, ok := meta.(*apiClient)
+	if !ok || !d.NewValueKnown("device") {
+		return deviceInfo{}, false
+	}
+
+	info, err := c.deviceInfo(ctx, d.Get("device").(string))
+	if err != nil {
+		tflog.Warn(ctx, "unable to discover the device capabilities, skipping feature checks", map[string]interface{}{"error": err.Error()})
+		return deviceInfo{}, false
+	}
+
+	return info, true
+}
+
+// requireVersion fails the plan when the device runs a version older than
+// min, which feature needs.
+func requireVersion(ctx context.Context, d *schema.ResourceDiff, meta interface{}, min string, feature string) error {
+	info, ok := planDeviceInfo(ctx, d, meta)
+	if !ok || info.atLeast(min) {
+		return nil
+	}
+	return fmt.Errorf("%s need IOS-XE %s or later, the device runs %s", feature, min, info.version)
+}
+
+// checkFeatures makes the plan of the resource name fail when the device does
+// not implement the resourceModules of name, before r's own CustomizeDiff.
+func checkFeatures(name string, r *schema.Resource) {
+	modules := resourceModules[name]
+	next := r.CustomizeDiff
+	if len(modules) == 0 {
+		return
+	}
+
+	r.CustomizeDiff = func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		if info, ok := planDeviceInfo(ctx, d, meta); ok {
+			if missing := info.missingModules(modules); len(missing) > 0 {
+				return fmt.Errorf("%s needs the YANG modules %s, which the device does not implement", name, strings.Join(missing, ", "))
+			}
+		}
+		if next != nil {
+			return next(ctx, d, meta)
+		}
+		return nil
+	}
+}
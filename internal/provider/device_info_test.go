@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/poroping/go-ios-xe-sdk/config"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf/restconftest"
+)
+
+func TestDeviceInfo_atLeast(t *testing.T) {
+	cases := []struct {
+		version string
+		min     string
+		want    bool
+	}{
+		{"", "16.9", true},
+		{"16.9", "16.9", true},
+		{"16.12", "16.9", true},
+		{"17.3", "16.9", true},
+		{"16.6", "16.9", false},
+		{"16", "16.9", false},
+		{"16.9.1", "16.9", true},
+	}
+
+	for _, c := range cases {
+		if got := (deviceInfo{version: c.version}).atLeast(c.min); got != c.want {
+			t.Errorf("%q at least %q: expected %t, got %t", c.version, c.min, c.want, got)
+		}
+	}
+}
+
+func TestDeviceInfo_retryAfterError(t *testing.T) {
+	srv := restconftest.NewServer()
+	defer srv.Close()
+	srv.Put(yangLibraryPath, `{"ietf-yang-library:modules-state": {"module": [{"name": "Cisco-IOS-XE-native", "revision": "2019-11-01"}]}}`)
+
+	c, err := newAPIClient(config.Config{
+		Host:     srv.Host,
+		Insecure: true,
+	}, restconf.Options{Insecure: true})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// e.g. the context of the provider configuration, cancelled by now
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.deviceInfo(ctx, ""); err == nil {
+		t.Fatalf("expected discovery with a cancelled context to fail")
+	}
+
+	info, err := c.deviceInfo(context.Background(), "")
+	if err != nil {
+		t.Fatalf("expected discovery to be retried, got %s", err)
+	}
+	if _, ok := info.modules["Cisco-IOS-XE-native"]; !ok {
+		t.Fatalf("expected the modules to be discovered, got %v", info.modules)
+	}
+}
@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/poroping/go-ios-xe-sdk/client"
+	"github.com/poroping/go-ios-xe-sdk/models"
+	"github.com/poroping/go-ios-xe-sdk/request"
+)
+
+// interfaceLeaves maps the attributes shared by the L3 interface resources to their YANG leaves.
+var interfaceLeaves = map[string]string{
+	"description": "description",
+	"shutdown":    "shutdown",
+	"vrf":         "vrf",
+}
+
+// clearedLeaves returns the leaves of attributes that changed to their zero
+// value in this update. Payloads only carry set values, so these have to be
+// deleted from the device explicitly.
+func clearedLeaves(d *schema.ResourceData, leaves map[string]string) []string {
+	attrs := make([]string, 0, len(leaves))
+	for attr := range leaves {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+
+	r := []string{}
+	for _, attr := range attrs {
+		if !d.HasChange(attr) {
+			continue
+		}
+		_, n := d.GetChange(attr)
+		if isZero(n) {
+			r = append(r, leaves[attr])
+		}
+	}
+
+	return r
+}
+
+func isZero(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case bool:
+		return !t
+	case int:
+		return t == 0
+	case []interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+// removedListKeys returns the values of field in the list entries of attr that
+// were removed in this update.
+func removedListKeys(d *schema.ResourceData, attr string, field string) []string {
+	if !d.HasChange(attr) {
+		return nil
+	}
+	o, n := d.GetChange(attr)
+
+	keep := map[string]bool{}
+	for _, v := range n.([]interface{}) {
+		if m, ok := v.(map[string]interface{}); ok {
+			keep[fmt.Sprint(m[field])] = true
+		}
+	}
+
+	r := []string{}
+	for _, v := range o.([]interface{}) {
+		if m, ok := v.(map[string]interface{}); ok {
+			k := fmt.Sprint(m[field])
+			if !keep[k] {
+				r = append(r, k)
+			}
+		}
+	}
+
+	return r
+}
+
+// removedSecondaryIPLeaves returns the secondary address entries removed in this update.
+func removedSecondaryIPLeaves(d *schema.ResourceData) []string {
+	r := []string{}
+	for _, cidr := range removedListKeys(d, "secondary_ip", "ip") {
+		ip := models.IPAddress{CIDR: cidr}
+		if err := ip.SetNetmask(); err != nil {
+			continue
+		}
+		r = append(r, fmt.Sprintf("ip/address/secondary=%s", ip.Address))
+	}
+
+	return r
+}
+
+// deleteLeaves deletes each leaf below path, leaves already absent are ignored.
+func deleteLeaves(c *client.CiscoIOSXEClient, path string, leaves []string) error {
+	for _, leaf := range leaves {
+		r := models.IOSXERequest{}
+		r.HTTPMethod = "DELETE"
+		r.Path = fmt.Sprintf("%s/%s", path, leaf)
+
+		err := request.Delete(&c.Config, &r)
+		if err != nil {
+			return fmt.Errorf("unable to delete %s. %s", leaf, err)
+		}
+	}
+
+	return nil
+}
@@ -2,11 +2,25 @@ package provider
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/poroping/go-ios-xe-sdk/client"
 	"github.com/poroping/go-ios-xe-sdk/config"
+	"github.com/poroping/terraform-provider-iosxe/internal/netconf"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
+	"golang.org/x/crypto/ssh"
 )
 
 func init() {
@@ -45,27 +59,221 @@ func New(version string) func() *schema.Provider {
 					Sensitive:   true,
 					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_PASSWORD", nil),
 				},
+				"token": {
+					Description: "Bearer token sent to RESTCONF devices instead of the username and password, for devices behind an authenticating proxy or gateway. NETCONF needs the username and password.",
+					Type:        schema.TypeString,
+					Optional:    true,
+					Sensitive:   true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_TOKEN", nil),
+				},
+				"password_file": {
+					Description: "Path of a file holding the password, used if `password` is unset.",
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_PASSWORD_FILE", nil),
+				},
+				"credentials_command": {
+					Description: "Command printing a JSON object with `username` and `password`, or `token`, used for those still unset. It is split on whitespace and run without a shell.",
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_CREDENTIALS_COMMAND", nil),
+				},
+				"credentials_profile": {
+					Description: "Profile of `credentials_file` to take the `username` and `password`, or `token`, still unset from.",
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_CREDENTIALS_PROFILE", nil),
+				},
+				"credentials_file": {
+					Description: "YAML or INI file with the credentials profiles.",
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_CREDENTIALS_FILE", defaultCredentialsFile),
+				},
 				"insecure": {
 					Type:        schema.TypeBool,
 					Optional:    true,
 					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_INSECURE", false),
 				},
+				"ca_cert_file": {
+					Description: "Path of a file with the PEM encoded CA certificates to verify the device certificate against instead of the system roots.",
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_CA_CERT_FILE", nil),
+				},
+				"ca_cert_pem": {
+					Description: "PEM encoded CA certificates to verify the device certificate against instead of the system roots.",
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_CA_CERT_PEM", nil),
+				},
+				"client_cert": {
+					Description: "PEM encoded client certificate for mutual TLS, or the path of a file holding it.",
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_CLIENT_CERT", nil),
+				},
+				"client_key": {
+					Description: "PEM encoded key of `client_cert`, or the path of a file holding it.",
+					Type:        schema.TypeString,
+					Optional:    true,
+					Sensitive:   true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_CLIENT_KEY", nil),
+				},
+				"tls_server_name": {
+					Description: "Name to verify the device certificate against, the host if unset.",
+					Type:        schema.TypeString,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_TLS_SERVER_NAME", nil),
+				},
+				"proxy_url": {
+					Description:  "URL of the proxy for requests to the devices, e.g. `http://proxy:3128` or `socks5://proxy:1080`. The proxy of the `HTTPS_PROXY` environment variable is used if unset.",
+					Type:         schema.TypeString,
+					Optional:     true,
+					DefaultFunc:  schema.EnvDefaultFunc("TF_IOSXE_PROXY_URL", nil),
+					ValidateFunc: validation.IsURLWithScheme([]string{"http", "https", "socks5"}),
+				},
+				"protocol": {
+					Description:  "`restconf`, or `netconf` for NETCONF over SSH on port 830 unless `host` has a port. NETCONF host keys are verified against `~/.ssh/known_hosts` unless `insecure` is set.",
+					Type:         schema.TypeString,
+					Optional:     true,
+					DefaultFunc:  schema.EnvDefaultFunc("TF_IOSXE_PROTOCOL", "restconf"),
+					ValidateFunc: validation.StringInSlice([]string{"restconf", "netconf"}, false),
+				},
+				"max_retries": {
+					Description:  "Number of times a request is retried when the device reports it is busy or its datastore is locked, and a GET, PUT or DELETE is retried when it fails to connect, loses its connection or times out.",
+					Type:         schema.TypeInt,
+					Optional:     true,
+					DefaultFunc:  schema.EnvDefaultFunc("TF_IOSXE_MAX_RETRIES", 10),
+					ValidateFunc: validation.IntAtLeast(0),
+				},
+				"retry_backoff": {
+					Description:      "Wait before the first retry, doubled on every further retry up to 30s.",
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.EnvDefaultFunc("TF_IOSXE_RETRY_BACKOFF", "1s"),
+					ValidateDiagFunc: validateDuration,
+				},
+				"request_timeout": {
+					Description:      "Timeout of a single request to the device.",
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.EnvDefaultFunc("TF_IOSXE_REQUEST_TIMEOUT", "30s"),
+					ValidateDiagFunc: validateDuration,
+				},
+				"max_concurrent_requests": {
+					Description:  "Maximum number of requests sent to the device at once, `0` for no limit.",
+					Type:         schema.TypeInt,
+					Optional:     true,
+					DefaultFunc:  schema.EnvDefaultFunc("TF_IOSXE_MAX_CONCURRENT_REQUESTS", 4),
+					ValidateFunc: validation.IntAtLeast(0),
+				},
+				"save_config_on_apply": {
+					Description: "Save the running config to the startup config whenever the last write in flight to a device finishes. Writes depending on each other are saved one by one.",
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_SAVE_CONFIG_ON_APPLY", false),
+				},
+				"rollback_on_failure": {
+					Description: "Take a checkpoint of the running config before the first write to a device and roll back to it when a write to the device fails.",
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_ROLLBACK_ON_FAILURE", false),
+				},
+				"confirmed_commit": {
+					Description: "Hold the writes to a device in the candidate datastore until `iosxe_commit`, or else the provider exiting, commits them with a confirmed commit, confirmed once every written resource reads back. Needs `protocol = \"netconf\"`.",
+					Type:        schema.TypeBool,
+					Optional:    true,
+					DefaultFunc: schema.EnvDefaultFunc("TF_IOSXE_CONFIRMED_COMMIT", false),
+				},
+				"confirm_timeout": {
+					Description:      "Time after which the device reverts a confirmed commit that was not confirmed.",
+					Type:             schema.TypeString,
+					Optional:         true,
+					DefaultFunc:      schema.EnvDefaultFunc("TF_IOSXE_CONFIRM_TIMEOUT", "10m"),
+					ValidateDiagFunc: validateDuration,
+				},
+				"devices": {
+					Description: "Additional devices managed by this provider, selected with the `device` argument of resources and data sources.",
+					Type:        schema.TypeList,
+					Optional:    true,
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"name": {
+								Description:  "Name referenced by the `device` argument.",
+								Type:         schema.TypeString,
+								Required:     true,
+								ValidateFunc: validation.StringIsNotWhiteSpace,
+							},
+							"host": {
+								Type:     schema.TypeString,
+								Required: true,
+							},
+							"username": {
+								Description: "Defaults to the provider `username`.",
+								Type:        schema.TypeString,
+								Optional:    true,
+							},
+							"password": {
+								Description: "Defaults to the provider `password`.",
+								Type:        schema.TypeString,
+								Optional:    true,
+								Sensitive:   true,
+							},
+							// a string as the SDK cannot tell an unset bool from false
+							"insecure": {
+								Description:  "`true` or `false`, defaults to the provider `insecure`.",
+								Type:         schema.TypeString,
+								Optional:     true,
+								ValidateFunc: validation.StringInSlice([]string{"true", "false"}, false),
+							},
+							"tls_server_name": {
+								Description: "Defaults to the provider `tls_server_name`.",
+								Type:        schema.TypeString,
+								Optional:    true,
+							},
+							"protocol": {
+								Description:  "Defaults to the provider `protocol`.",
+								Type:         schema.TypeString,
+								Optional:     true,
+								ValidateFunc: validation.StringInSlice([]string{"restconf", "netconf"}, false),
+							},
+						},
+					},
+				},
 			},
 			DataSourcesMap: map[string]*schema.Resource{
-				// "iosxe_interface_vlan": dataSourceVlan(),
+				"iosxe_device":                 dataSourceDevice(),
+				"iosxe_interface":              dataSourceInterface(),
+				"iosxe_interface_port_channel": dataSourcePortChannel(),
+				"iosxe_interface_vlan":         dataSourceVlan(),
+				"iosxe_interfaces":             dataSourceInterfaces(),
+				"iosxe_restconf":               dataSourceRestconf(),
 			},
 			ResourcesMap: map[string]*schema.Resource{
 				"iosxe_interface_port_channel":              resourcePortChannel(),
 				"iosxe_interface_port_channel_subinterface": resourcePortChannelSubinterface(),
 				"iosxe_interface_vlan":                      resourceVlan(),
 				// "iosxe_l3_interface":                        resourceL3Interface(),
-				"iosxe_l2_vlan":      resourceL2Vlan(),
-				"iosxe_bgp_router":   resourceBgpRouter(),
-				"iosxe_bgp_neighbor": resourceBgpNeighbor(),
-				"iosxe_vrf":          resourceVRF(),
+				"iosxe_l2_vlan":        resourceL2Vlan(),
+				"iosxe_bgp_router":     resourceBgpRouter(),
+				"iosxe_bgp_neighbor":   resourceBgpNeighbor(),
+				"iosxe_bgp_peer_group": resourceBgpPeerGroup(),
+				"iosxe_vrf":            resourceVRF(),
+				"iosxe_restconf":       resourceRestconf(),
+				"iosxe_cli":            resourceCLI(),
+				"iosxe_save_config":    resourceSaveConfig(),
+				"iosxe_commit":         resourceCommit(),
 			},
 		}
 
+		for name, r := range p.ResourcesMap {
+			if name != "iosxe_save_config" && name != "iosxe_commit" {
+				trackWrites(name, r)
+			}
+			checkFeatures(name, r)
+		}
+
 		p.ConfigureContextFunc = configure(version, p)
 
 		return p
@@ -74,19 +282,49 @@ func New(version string) func() *schema.Provider {
 
 type apiClient struct {
 	Client *client.CiscoIOSXEClient
-	// Add whatever fields, client or connection info, etc. here
-	// you would need to setup to communicate with the upstream
-	// API.
+
+	// devices holds the config of the provider's additional devices, their
+	// clients are created on first use
+	devices  map[string]deviceConfig
+	opts     restconf.Options
+	protocol string
+
+	saveConfigOnApply bool
+	rollbackOnFailure bool
+	confirmedCommit   bool
+	confirmTimeout    time.Duration
+
+	mu      sync.Mutex
+	clients map[string]*client.CiscoIOSXEClient
+	// writes tracks the resource writes per device name
+	writes map[string]*deviceWrites
+	// transports holds the NETCONF transports per device name, "" for the
+	// provider host
+	transports map[string]*netconf.Transport
+	// discoveries holds what the devices support per device name
+	discoveries map[string]*discovery
+}
+
+type deviceConfig struct {
+	config.Config
+	protocol      string
+	tlsServerName string
+	// token is the provider token, unless the device sets its own username
+	// or password
+	token string
 }
 
 func configure(version string, p *schema.Provider) func(context.Context, *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	return func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-		username := d.Get("username").(string)
-		password := d.Get("password").(string)
+		creds, err := resolveCredentials(ctx, d)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		username := creds.Username
+		password := creds.Password
 		host := d.Get("host").(string)
 		insecure := d.Get("insecure").(bool)
 		userAgent := p.UserAgent("terraform-provider-iosxe", version)
-		var diags diag.Diagnostics
 		cfg := config.Config{
 			Username:  username,
 			Password:  password,
@@ -94,12 +332,298 @@ func configure(version string, p *schema.Provider) func(context.Context, *schema
 			Insecure:  insecure,
 			UserAgent: userAgent,
 		}
-		c, err := client.NewClient(cfg)
-		diags = append(diags, diag.FromErr(err)...)
-		apiClient := &apiClient{
-			Client: c,
+		// durations are validated by the schema
+		retryBackoff, _ := time.ParseDuration(d.Get("retry_backoff").(string))
+		requestTimeout, _ := time.ParseDuration(d.Get("request_timeout").(string))
+		opts := restconf.Options{
+			Insecure:              insecure,
+			MaxRetries:            d.Get("max_retries").(int),
+			RetryBackoff:          retryBackoff,
+			RequestTimeout:        requestTimeout,
+			MaxConcurrentRequests: d.Get("max_concurrent_requests").(int),
+		}
+		tlsConfig, err := providerTLSConfig(d)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		opts.TLSConfig = tlsConfig
+		if v := d.Get("proxy_url").(string); v != "" {
+			// validated by the schema
+			opts.Proxy, _ = url.Parse(v)
+		}
+		protocol := d.Get("protocol").(string)
+		if creds.Token != "" && host != "" && protocol == "netconf" {
+			return nil, diag.Errorf("token needs protocol restconf, NETCONF needs the username and password")
+		}
+		opts.Token = creds.Token
+		confirmedCommit := d.Get("confirmed_commit").(bool)
+		if confirmedCommit && host != "" && protocol != "netconf" {
+			return nil, diag.Errorf("confirmed_commit needs protocol netconf")
+		}
+		transport, err := newTransport(protocol, insecure, confirmedCommit)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		opts.Transport = transport
+		apiClient, err := newAPIClient(cfg, opts)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		if t, ok := transport.(*netconf.Transport); ok {
+			apiClient.transports[""] = t
+		}
+		apiClient.protocol = protocol
+		apiClient.saveConfigOnApply = d.Get("save_config_on_apply").(bool)
+		apiClient.rollbackOnFailure = d.Get("rollback_on_failure").(bool)
+		apiClient.confirmedCommit = confirmedCommit
+		apiClient.confirmTimeout, _ = time.ParseDuration(d.Get("confirm_timeout").(string))
+		if confirmedCommit {
+			registerStaging(apiClient)
+		}
+
+		for _, v := range d.Get("devices").([]interface{}) {
+			dev := v.(map[string]interface{})
+			name := dev["name"].(string)
+			if _, ok := apiClient.devices[name]; ok {
+				return nil, diag.Errorf("device %q is configured more than once", name)
+			}
+
+			devCfg := deviceConfig{Config: cfg, protocol: protocol, token: creds.Token}
+			devCfg.Host = dev["host"].(string)
+			if v := dev["username"].(string); v != "" {
+				devCfg.Username = v
+				devCfg.token = ""
+			}
+			if v := dev["password"].(string); v != "" {
+				devCfg.Password = v
+				devCfg.token = ""
+			}
+			if v := dev["insecure"].(string); v != "" {
+				// validated by the schema
+				devCfg.Insecure, _ = strconv.ParseBool(v)
+			}
+			devCfg.tlsServerName = dev["tls_server_name"].(string)
+			if v := dev["protocol"].(string); v != "" {
+				devCfg.protocol = v
+			}
+			if devCfg.token != "" && devCfg.protocol == "netconf" {
+				return nil, diag.Errorf("token needs protocol restconf, set the username and password of device %q", name)
+			}
+			if confirmedCommit && devCfg.protocol != "netconf" {
+				return nil, diag.Errorf("confirmed_commit needs protocol netconf, device %q uses %s", name, devCfg.protocol)
+			}
+			apiClient.devices[name] = devCfg
+		}
+
+		var diags diag.Diagnostics
+		if host != "" {
+			if _, err := apiClient.deviceInfo(ctx, ""); err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Warning,
+					Summary:  "Unable to discover the device capabilities, discovery is retried when resources are planned",
+					Detail:   err.Error(),
+				})
+			}
 		}
 
 		return apiClient, diags
 	}
 }
+
+func newAPIClient(cfg config.Config, opts restconf.Options) (*apiClient, error) {
+	c, err := client.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// replace the sdk's retrying client with one honouring the provider settings
+	c.Config.HTTPCon = restconf.NewHTTPClient(opts)
+
+	return &apiClient{
+		Client:      c,
+		devices:     map[string]deviceConfig{},
+		opts:        opts,
+		clients:     map[string]*client.CiscoIOSXEClient{},
+		writes:      map[string]*deviceWrites{},
+		transports:  map[string]*netconf.Transport{},
+		discoveries: map[string]*discovery{},
+	}, nil
+}
+
+// deviceClient returns the client of the device selected by the resource's
+// device argument. Its requests are aborted when ctx is cancelled or the
+// operation timeout expires.
+func (c *apiClient) deviceClient(ctx context.Context, d *schema.ResourceData) (*client.CiscoIOSXEClient, error) {
+	dc, err := c.device(d.Get("device").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	cc := *dc
+	cc.Config.HTTPCon = restconf.WithContext(ctx, dc.Config.HTTPCon)
+
+	return &cc, nil
+}
+
+// restconfClient returns a client for arbitrary RESTCONF paths on the device
+// selected by the resource's device argument.
+func (c *apiClient) restconfClient(d *schema.ResourceData) (*restconf.Client, error) {
+	return c.deviceRestconfClient(d.Get("device").(string))
+}
+
+// deviceRestconfClient returns a client for arbitrary RESTCONF paths on the
+// device name.
+func (c *apiClient) deviceRestconfClient(name string) (*restconf.Client, error) {
+	dc, err := c.device(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &restconf.Client{
+		HTTPClient: dc.Config.HTTPCon,
+		Host:       dc.Config.Host,
+		Username:   dc.Config.Username,
+		Password:   dc.Config.Password,
+		UserAgent:  dc.Config.UserAgent,
+	}, nil
+}
+
+func (c *apiClient) device(name string) (*client.CiscoIOSXEClient, error) {
+	if name == "" {
+		if c.Client.Config.Host == "" {
+			return nil, fmt.Errorf("no host configured, set the provider host or select one of the provider devices with device")
+		}
+		return c.Client, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if dc, ok := c.clients[name]; ok {
+		return dc, nil
+	}
+
+	cfg, ok := c.devices[name]
+	if !ok {
+		return nil, fmt.Errorf("device %q is not configured in the provider devices", name)
+	}
+
+	opts := c.opts
+	opts.Insecure = cfg.Insecure
+	opts.Token = cfg.token
+	if cfg.tlsServerName != "" {
+		opts.TLSConfig = opts.TLSConfig.Clone()
+		opts.TLSConfig.ServerName = cfg.tlsServerName
+	}
+	transport, err := newTransport(cfg.protocol, cfg.Insecure, c.confirmedCommit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create client for device %q. %s", name, err)
+	}
+	opts.Transport = transport
+	dc, err := newAPIClient(cfg.Config, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create client for device %q. %s", name, err)
+	}
+	c.clients[name] = dc.Client
+	if t, ok := transport.(*netconf.Transport); ok {
+		c.transports[name] = t
+	}
+
+	return dc.Client, nil
+}
+
+// newTransport returns the transport for requests to a device using
+// protocol, nil for RESTCONF over HTTPS.
+func newTransport(protocol string, insecure bool, confirmedCommit bool) (http.RoundTripper, error) {
+	if protocol != "netconf" {
+		return nil, nil
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if !insecure {
+		cb, err := netconf.KnownHosts()
+		if err != nil {
+			return nil, fmt.Errorf("unable to load known hosts for netconf, set insecure to skip host key verification. %s", err)
+		}
+		hostKeyCallback = cb
+	}
+
+	return &netconf.Transport{HostKeyCallback: hostKeyCallback, ConfirmedCommit: confirmedCommit}, nil
+}
+
+// providerTLSConfig returns the TLS config of the provider arguments.
+func providerTLSConfig(d *schema.ResourceData) (*tls.Config, error) {
+	caPEM := []byte(d.Get("ca_cert_pem").(string))
+	if f := d.Get("ca_cert_file").(string); f != "" {
+		if len(caPEM) > 0 {
+			return nil, fmt.Errorf("only one of ca_cert_file and ca_cert_pem can be set")
+		}
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ca_cert_file. %s", err)
+		}
+		caPEM = b
+	}
+
+	certPEM, err := pemOrFile(d.Get("client_cert").(string))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read client_cert. %s", err)
+	}
+	keyPEM, err := pemOrFile(d.Get("client_key").(string))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read client_key. %s", err)
+	}
+	if (len(certPEM) == 0) != (len(keyPEM) == 0) {
+		return nil, fmt.Errorf("client_cert and client_key have to be set together")
+	}
+
+	return restconf.TLSConfig(caPEM, certPEM, keyPEM, d.Get("tls_server_name").(string))
+}
+
+// pemOrFile returns v if it is PEM encoded, the content of the file v
+// otherwise.
+func pemOrFile(v string) ([]byte, error) {
+	if v == "" || strings.HasPrefix(strings.TrimSpace(v), "-----BEGIN") {
+		return []byte(v), nil
+	}
+	return os.ReadFile(v)
+}
+
+// deviceSchema is the device argument shared by all resources and data sources.
+func deviceSchema() *schema.Schema {
+	return &schema.Schema{
+		Description: "Name of the provider `devices` entry to manage, the provider `host` if unset.",
+		Type:        schema.TypeString,
+		Optional:    true,
+		ForceNew:    true,
+	}
+}
+
+// importStateDevice wraps an importer to accept "<id>@<device>" for resources
+// on one of the provider devices.
+func importStateDevice(f schema.StateContextFunc) schema.StateContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+		if i := strings.LastIndex(d.Id(), "@"); i >= 0 {
+			if err := d.Set("device", d.Id()[i+1:]); err != nil {
+				return nil, err
+			}
+			d.SetId(d.Id()[:i])
+		}
+
+		return f(ctx, d, meta)
+	}
+}
+
+func validateDuration(v interface{}, path cty.Path) diag.Diagnostics {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		return diag.Diagnostics{
+			{
+				Severity:      diag.Error,
+				Summary:       "Invalid duration",
+				Detail:        err.Error(),
+				AttributePath: path,
+			},
+		}
+	}
+	return nil
+}
@@ -1,12 +1,23 @@
 package provider
 
 import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/poroping/go-ios-xe-sdk/config"
+	"github.com/poroping/terraform-provider-iosxe/internal/netconf/netconftest"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf/restconftest"
 )
 
 const (
@@ -44,6 +55,67 @@ func testAccPreCheck(t *testing.T) {
 	}
 }
 
+// testAccMockDevice starts an in-memory RESTCONF device and points the
+// provider at it through the usual environment variables, so acceptance tests
+// can run without hardware.
+func testAccMockDevice(t *testing.T) *restconftest.Server {
+	srv := restconftest.NewServer()
+	t.Cleanup(srv.Close)
+
+	t.Setenv("TF_IOSXE_HOST", srv.Host)
+	t.Setenv("TF_IOSXE_USERNAME", "admin")
+	t.Setenv("TF_IOSXE_PASSWORD", "admin")
+	t.Setenv("TF_IOSXE_INSECURE", "true")
+
+	return srv
+}
+
+func testAccCheckMockExists(srv *restconftest.Server, path string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if !srv.Exists(path) {
+			return fmt.Errorf("%s not found on device", path)
+		}
+		return nil
+	}
+}
+
+func testAccCheckMockDeleted(srv *restconftest.Server, paths ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, path := range paths {
+			// requests record the decoded path, keys may hold escapes
+			decoded, err := url.PathUnescape(path)
+			if err != nil {
+				return err
+			}
+			found := false
+			for _, r := range srv.Requests() {
+				if r.Method == "DELETE" && r.Path == restconftest.DataPath+decoded {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("no DELETE received for %s", path)
+			}
+			if srv.Exists(path) {
+				return fmt.Errorf("%s still present on device", path)
+			}
+		}
+		return nil
+	}
+}
+
+func testAccCheckMockDestroy(srv *restconftest.Server, paths ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, path := range paths {
+			if srv.Exists(path) {
+				return fmt.Errorf("%s still present on device", path)
+			}
+		}
+		return nil
+	}
+}
+
 func testAccCreateResourceFromExampleStep(rName string) resource.TestStep {
 	// skip test if no example is provided
 	if testAccExampleResourceConfig(rName) == "" {
@@ -57,6 +129,21 @@ func testAccCreateResourceFromExampleStep(rName string) resource.TestStep {
 	}
 }
 
+func testAccImportResourceFromExampleStep(rName string, ignore ...string) resource.TestStep {
+	// skip test if no example is provided
+	if testAccExampleResourceConfig(rName) == "" {
+		return resource.TestStep{
+			SkipFunc: testAccSkipTestStep,
+		}
+	}
+	return resource.TestStep{
+		ResourceName:            fmt.Sprintf("%s.example", rName),
+		ImportState:             true,
+		ImportStateVerify:       true,
+		ImportStateVerifyIgnore: ignore,
+	}
+}
+
 func testAccExampleResourceConfig(rName string) string {
 	b, err := os.ReadFile(fmt.Sprintf("%s/resources/%s/resource.tf", examplesDir, rName))
 	if err != nil {
@@ -68,3 +155,663 @@ func testAccExampleResourceConfig(rName string) string {
 func testAccSkipTestStep() (bool, error) {
 	return true, nil
 }
+
+// testResourceReadNotFound runs the resource's Read against a device with no
+// config and checks the resource is dropped from state instead of erroring.
+func testResourceReadNotFound(t *testing.T, r *schema.Resource, id string) {
+	srv := restconftest.NewServer()
+	defer srv.Close()
+
+	meta, err := newAPIClient(config.Config{
+		Host:     srv.Host,
+		Insecure: true,
+	}, restconf.Options{Insecure: true})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	d := r.TestResourceData()
+	d.SetId(id)
+
+	if diags := r.ReadContext(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected resource to be removed from state, still has ID %q", d.Id())
+	}
+}
+
+func TestProvider_resourceTimeouts(t *testing.T) {
+	for name, r := range New("dev")().ResourcesMap {
+		if r.Timeouts == nil || r.Timeouts.Create == nil || r.Timeouts.Read == nil || r.Timeouts.Delete == nil {
+			t.Errorf("%s: expected create, read and delete timeouts", name)
+		}
+		if r.UpdateContext != nil && (r.Timeouts == nil || r.Timeouts.Update == nil) {
+			t.Errorf("%s: expected update timeout", name)
+		}
+	}
+}
+
+func TestProvider_contextCancelled(t *testing.T) {
+	srv := restconftest.NewServer()
+	defer srv.Close()
+
+	meta, err := newAPIClient(config.Config{
+		Host:     srv.Host,
+		Insecure: true,
+	}, restconf.Options{Insecure: true})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	r := resourceVRF()
+	d := r.TestResourceData()
+	d.Set("name", "FOOBAR")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	diags := r.CreateContext(ctx, d, meta)
+	if !diags.HasError() {
+		t.Fatalf("expected create to fail with a cancelled context")
+	}
+	if !strings.Contains(diags[0].Summary, context.Canceled.Error()) {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(srv.Requests()) != 0 {
+		t.Fatalf("expected no requests to reach the device, got %v", srv.Requests())
+	}
+}
+
+func TestProvider_devices(t *testing.T) {
+	srv := testAccMockDevice(t)
+	sw2 := restconftest.NewServer()
+	t.Cleanup(sw2.Close)
+	path := "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(sw2, path),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccProviderDevicesConfig, sw2.Host),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(sw2, path),
+					testAccCheckMockDestroy(srv, path),
+					resource.TestCheckResourceAttr("iosxe_vrf.example", "device", "sw2"),
+				),
+			},
+			{
+				ResourceName:      "iosxe_vrf.example",
+				ImportState:       true,
+				ImportStateId:     "FOOBAR@sw2",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+const testAccProviderDevicesConfig = `
+provider "iosxe" {
+  devices {
+    name = "sw2"
+    host = "%s"
+  }
+}
+
+resource "iosxe_vrf" "example" {
+  device      = "sw2"
+  name        = "FOOBAR"
+  rd          = "566:4560"
+}
+`
+
+// testAccMockNetconfDevice starts an in-memory NETCONF device and points the
+// provider at it through the usual environment variables.
+func testAccMockNetconfDevice(t *testing.T, candidate bool) *netconftest.Server {
+	srv := netconftest.NewServer(candidate)
+	t.Cleanup(srv.Close)
+
+	t.Setenv("TF_IOSXE_HOST", srv.Host)
+	t.Setenv("TF_IOSXE_USERNAME", "admin")
+	t.Setenv("TF_IOSXE_PASSWORD", "admin")
+	t.Setenv("TF_IOSXE_INSECURE", "true")
+	t.Setenv("TF_IOSXE_PROTOCOL", "netconf")
+
+	return srv
+}
+
+func TestProvider_mockNetconf(t *testing.T) {
+	for _, candidate := range []bool{false, true} {
+		t.Run(fmt.Sprintf("candidate=%t", candidate), func(t *testing.T) {
+			srv := testAccMockNetconfDevice(t, candidate)
+			paths := []string{
+				"Cisco-IOS-XE-native:native/vrf/definition=FOOBAR",
+				"Cisco-IOS-XE-native:native/vlan/Cisco-IOS-XE-vlan:vlan-list=666",
+				"Cisco-IOS-XE-native:native/interface/Vlan=666",
+			}
+
+			resource.Test(t, resource.TestCase{
+				ProviderFactories: providerFactories,
+				CheckDestroy: func(s *terraform.State) error {
+					for _, path := range paths {
+						if srv.Exists(path) {
+							return fmt.Errorf("%s still present on device", path)
+						}
+					}
+					return nil
+				},
+				Steps: []resource.TestStep{
+					{
+						Config: testAccExampleResourceConfig("iosxe_interface_vlan"),
+						Check: func(s *terraform.State) error {
+							for _, path := range paths {
+								if !srv.Exists(path) {
+									return fmt.Errorf("%s not found on device: %s", path, srv.Running())
+								}
+							}
+							return nil
+						},
+					},
+					testAccImportResourceFromExampleStep("iosxe_interface_vlan"),
+				},
+			})
+		})
+	}
+}
+
+func TestProvider_mockConfirmedCommit(t *testing.T) {
+	srv := testAccMockNetconfDevice(t, true)
+	checkDescription := func(path, want string) {
+		n, ok := srv.Get(path + "/description")
+		if !ok || n.Text != want {
+			t.Fatalf("expected description %q on %s: %s", want, path, srv.Running())
+		}
+		if srv.Confirming() {
+			t.Fatalf("commit left unconfirmed")
+		}
+	}
+	checkDescriptions := func(want string) {
+		checkDescription("Cisco-IOS-XE-native:native/vrf/definition=MGMT", want)
+		checkDescription("Cisco-IOS-XE-native:native/interface/Vlan=666", want)
+	}
+	// a confirmed commit and the commit confirming it
+	checkCommits := func(s *terraform.State) error {
+		n := 0
+		for _, op := range srv.Requests() {
+			if op == "commit" {
+				n++
+			}
+		}
+		if n != 2 {
+			return fmt.Errorf("expected a single confirmed commit, got %d commits: %v", n, srv.Requests())
+		}
+		return nil
+	}
+	extra := "Cisco-IOS-XE-native:native/vlan/Cisco-IOS-XE-vlan:vlan-list=667"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfirmedCommitConfig("one", true),
+				Check: resource.ComposeTestCheckFunc(
+					checkCommits,
+					resource.TestCheckResourceAttr("iosxe_commit.mgmt", "result", "committed 4 resources"),
+				),
+			},
+			{
+				PreConfig: func() {
+					checkDescriptions("one")
+					// the device becomes unreachable once the change is committed
+					srv.DropAfter("commit")
+				},
+				Config:      testAccProviderConfirmedCommitConfig("two", true),
+				ExpectError: regexp.MustCompile("after the commit, it is reverted"),
+			},
+			{
+				PreConfig: func() {
+					checkDescriptions("one")
+				},
+				Config:             testAccProviderConfirmedCommitConfig("two", true),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				PreConfig: func() {
+					srv.ResetRequests()
+				},
+				// deletes are committed together with the updates
+				Config: testAccProviderConfirmedCommitConfig("two", false),
+				Check: resource.ComposeTestCheckFunc(
+					func(s *terraform.State) error {
+						checkDescriptions("two")
+						if srv.Exists(extra) {
+							return fmt.Errorf("expected %s to be deleted: %s", extra, srv.Running())
+						}
+						return nil
+					},
+					checkCommits,
+					resource.TestCheckResourceAttr("iosxe_commit.mgmt", "result", "committed 3 resources"),
+				),
+			},
+		},
+	})
+}
+
+func testAccProviderConfirmedCommitConfig(description string, extra bool) string {
+	config := fmt.Sprintf(`
+provider "iosxe" {
+  confirmed_commit = true
+  confirm_timeout  = "1m"
+}
+
+resource "iosxe_vrf" "mgmt" {
+  name        = "MGMT"
+  rd          = "566:1"
+  description = %[1]q
+}
+
+resource "iosxe_l2_vlan" "mgmt" {
+  vlanid = 666
+  name   = "MGMT"
+}
+
+resource "iosxe_interface_vlan" "mgmt" {
+  vlanid      = iosxe_l2_vlan.mgmt.vlanid
+  description = %[1]q
+  ip          = "192.168.66.6/24"
+  vrf         = iosxe_vrf.mgmt.name
+}
+`, description)
+	if !extra {
+		return config + `
+resource "iosxe_commit" "mgmt" {
+  depends_on = [iosxe_vrf.mgmt, iosxe_l2_vlan.mgmt, iosxe_interface_vlan.mgmt]
+}
+`
+	}
+
+	return config + `
+resource "iosxe_l2_vlan" "extra" {
+  vlanid = 667
+  name   = "EXTRA"
+}
+
+resource "iosxe_commit" "mgmt" {
+  depends_on = [iosxe_vrf.mgmt, iosxe_l2_vlan.mgmt, iosxe_interface_vlan.mgmt, iosxe_l2_vlan.extra]
+}
+`
+}
+
+func TestProvider_mockConfirmedCommitWithoutCommit(t *testing.T) {
+	srv := testAccMockNetconfDevice(t, true)
+	path := "Cisco-IOS-XE-native:native/vrf/definition=MGMT"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy: func(s *terraform.State) error {
+			if !srv.Exists(path) {
+				return fmt.Errorf("expected the delete to be staged until the provider exits")
+			}
+			CommitStaged()
+			return testAccCheckMockNetconfDeleted(srv, path)
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfirmedCommitWithoutCommitConfig,
+				Check: func(s *terraform.State) error {
+					if srv.Exists(path) {
+						return fmt.Errorf("expected the write to be staged until the provider exits")
+					}
+					// as when Terraform stops the provider after the apply
+					CommitStaged()
+					if !srv.Exists(path) || srv.Confirming() {
+						return fmt.Errorf("expected %s to be committed: %s", path, srv.Running())
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func testAccCheckMockNetconfDeleted(srv *netconftest.Server, path string) error {
+	if srv.Exists(path) {
+		return fmt.Errorf("expected %s to be deleted: %s", path, srv.Running())
+	}
+	return nil
+}
+
+const testAccProviderConfirmedCommitWithoutCommitConfig = `
+provider "iosxe" {
+  confirmed_commit = true
+}
+
+resource "iosxe_vrf" "mgmt" {
+  name = "MGMT"
+  rd   = "566:1"
+}
+`
+
+func TestProvider_mockCACert(t *testing.T) {
+	srv := testAccMockDevice(t)
+	t.Setenv("TF_IOSXE_INSECURE", "false")
+	t.Setenv("TF_IOSXE_CA_CERT_PEM", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})))
+	path := "Cisco-IOS-XE-native:native/vrf/definition=FOO"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProviderTLSServerNameConfig("wrong.example"),
+				ExpectError: regexp.MustCompile("certificate"),
+			},
+			{
+				Config: testAccProviderTLSServerNameConfig("example.com"),
+				Check:  testAccCheckMockExists(srv, path),
+			},
+		},
+	})
+}
+
+func testAccProviderTLSServerNameConfig(name string) string {
+	return fmt.Sprintf(`
+provider "iosxe" {
+  tls_server_name = %q
+}
+
+resource "iosxe_vrf" "foo" {
+  name = "FOO"
+  rd   = "566:1"
+}
+`, name)
+}
+
+func TestProvider_deviceInsecure(t *testing.T) {
+	cases := []struct {
+		insecure       bool
+		deviceInsecure interface{}
+		want           bool
+	}{
+		{false, nil, false},
+		{true, nil, true},
+		{false, "true", true},
+		{true, "false", false},
+	}
+
+	for _, c := range cases {
+		device := map[string]interface{}{
+			"name": "sw2",
+			"host": "192.0.2.2",
+		}
+		if c.deviceInsecure != nil {
+			device["insecure"] = c.deviceInsecure
+		}
+		p := New("test")()
+		diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+			"insecure": c.insecure,
+			"devices":  []interface{}{device},
+		}))
+		if diags.HasError() {
+			t.Fatalf("err: %v", diags)
+		}
+
+		if got := p.Meta().(*apiClient).devices["sw2"].Insecure; got != c.want {
+			t.Errorf("insecure %t, device insecure %v: expected %t, got %t", c.insecure, c.deviceInsecure, c.want, got)
+		}
+	}
+}
+
+func TestProvider_deviceToken(t *testing.T) {
+	p := New("test")()
+	diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"token": "s3cr3t",
+		"devices": []interface{}{
+			map[string]interface{}{"name": "sw2", "host": "192.0.2.2"},
+			map[string]interface{}{"name": "sw3", "host": "192.0.2.3", "username": "admin", "password": "admin"},
+		},
+	}))
+	if diags.HasError() {
+		t.Fatalf("err: %v", diags)
+	}
+
+	c := p.Meta().(*apiClient)
+	if c.opts.Token != "s3cr3t" || c.devices["sw2"].token != "s3cr3t" {
+		t.Errorf("expected the provider and sw2 to use the token")
+	}
+	if c.devices["sw3"].token != "" {
+		t.Errorf("expected sw3 to use its own username and password")
+	}
+
+	diags = New("test")().Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"token": "s3cr3t",
+		"devices": []interface{}{
+			map[string]interface{}{"name": "sw2", "host": "192.0.2.2", "protocol": "netconf"},
+		},
+	}))
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "token needs protocol restconf") {
+		t.Errorf("expected an error for a NETCONF device with the token, got %v", diags)
+	}
+}
+
+func TestProvider_unknownDevice(t *testing.T) {
+	meta, err := newAPIClient(config.Config{Host: "192.0.2.1"}, restconf.Options{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	r := resourceVRF()
+	d := r.TestResourceData()
+	d.Set("device", "sw2")
+
+	_, err = meta.deviceClient(context.Background(), d)
+	if err == nil || !strings.Contains(err.Error(), `device "sw2" is not configured`) {
+		t.Fatalf("expected unknown device error, got %v", err)
+	}
+}
+
+func TestProvider_mockSaveConfigOnApply(t *testing.T) {
+	srv := testAccMockDevice(t)
+	testAccMockSaveConfig(srv)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockSavedLast(srv),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderSaveConfigOnApplyConfig,
+				Check:  testAccCheckMockSavedLast(srv),
+			},
+		},
+	})
+}
+
+const testAccProviderSaveConfigOnApplyConfig = `
+provider "iosxe" {
+  save_config_on_apply = true
+}
+
+resource "iosxe_vrf" "foo" {
+  name = "FOO"
+  rd   = "566:1"
+}
+
+resource "iosxe_vrf" "bar" {
+  name = "BAR"
+  rd   = "566:2"
+}
+
+resource "iosxe_l2_vlan" "example" {
+  vlanid = 420
+  name   = iosxe_vrf.foo.name
+}
+`
+
+func TestProvider_mockSaveConfigOnApplySequential(t *testing.T) {
+	srv := testAccMockDevice(t)
+	testAccMockSaveConfig(srv)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderSaveConfigOnApplySequentialConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockSavedLast(srv),
+					// each write depends on the one before and finishes with
+					// no other write in flight
+					func(s *terraform.State) error {
+						if n := testAccMockSaves(srv); n != 3 {
+							return fmt.Errorf("expected a save after each of the 3 writes, got %d", n)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+const testAccProviderSaveConfigOnApplySequentialConfig = `
+provider "iosxe" {
+  save_config_on_apply = true
+}
+
+resource "iosxe_vrf" "foo" {
+  name = "FOO"
+  rd   = "566:1"
+}
+
+resource "iosxe_l2_vlan" "first" {
+  vlanid = 420
+  name   = iosxe_vrf.foo.name
+}
+
+resource "iosxe_l2_vlan" "second" {
+  vlanid = 421
+  name   = iosxe_l2_vlan.first.name
+}
+`
+
+// testAccMockSaveConfig implements the save-config operation on the device.
+func testAccMockSaveConfig(srv *restconftest.Server) {
+	srv.HandleRPC(saveConfigRPC, func(input map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"result": "Save running-config successful"}, nil
+	})
+}
+
+func testAccMockSaves(srv *restconftest.Server) int {
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Method == "POST" && r.Path == restconftest.OperationsPath+saveConfigRPC {
+			n++
+		}
+	}
+	return n
+}
+
+// testAccCheckMockSavedLast checks the config was saved after the last write.
+func testAccCheckMockSavedLast(srv *restconftest.Server) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		var last restconftest.Request
+		for _, r := range srv.Requests() {
+			if r.Method != "GET" && r.Method != "HEAD" {
+				last = r
+			}
+		}
+		if last.Method != "POST" || last.Path != restconftest.OperationsPath+saveConfigRPC {
+			return fmt.Errorf("expected the last write to be a save, got %s %s", last.Method, last.Path)
+		}
+		return nil
+	}
+}
+
+func TestProvider_mockRollbackOnFailure(t *testing.T) {
+	srv := testAccMockDevice(t)
+	rollbacks := testAccMockCheckpoint(srv)
+	path := "Cisco-IOS-XE-native:native/vrf/definition=FOO"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProviderRollbackOnFailureConfig,
+				ExpectError: regexp.MustCompile("error creating Cisco-IOS-XE-native:native/nonexistent/foo"),
+			},
+			{
+				PreConfig: func() {
+					if *rollbacks != 1 {
+						t.Fatalf("expected 1 rollback, got %d", *rollbacks)
+					}
+					if srv.Exists(path) {
+						t.Fatalf("%s still present after rollback", path)
+					}
+				},
+				Config:             testAccProviderRollbackOnFailureConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+const testAccProviderRollbackOnFailureConfig = `
+provider "iosxe" {
+  rollback_on_failure = true
+}
+
+resource "iosxe_vrf" "foo" {
+  name = "FOO"
+  rd   = "566:1"
+}
+
+resource "iosxe_restconf" "broken" {
+  path       = "Cisco-IOS-XE-native:native/nonexistent/foo"
+  method     = "PATCH"
+  attributes = jsonencode({ name = iosxe_vrf.foo.name })
+}
+`
+
+// testAccMockCheckpoint implements the checkpoint and rollback operations on
+// the device and returns the number of rollbacks.
+func testAccMockCheckpoint(srv *restconftest.Server) *int {
+	var checkpoint string
+	rollbacks := 0
+
+	srv.HandleRPC(checkpointRPC, func(input map[string]interface{}) (map[string]interface{}, error) {
+		v, _ := srv.Get(restconftest.NativeRoot)
+		b, _ := json.Marshal(map[string]interface{}{restconftest.NativeRoot: v})
+		checkpoint = string(b)
+		return map[string]interface{}{"result": "Checkpoint successful"}, nil
+	})
+	srv.HandleRPC(rollbackRPC, func(input map[string]interface{}) (map[string]interface{}, error) {
+		if input["target-url"] != checkpointURL {
+			return nil, fmt.Errorf("unexpected target-url %v", input["target-url"])
+		}
+		rollbacks++
+		return map[string]interface{}{"result": "Rollback successful"}, srv.Put(restconftest.NativeRoot, checkpoint)
+	})
+
+	return &rollbacks
+}
+
+func TestProvider_mockMissingModule(t *testing.T) {
+	srv := testAccMockDevice(t)
+	srv.Put("ietf-yang-library:modules-state", `{"ietf-yang-library:modules-state": {"module": [{"name": "Cisco-IOS-XE-native", "revision": "2019-11-01"}]}}`)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccExampleResourceConfig("iosxe_l2_vlan"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`iosxe_l2_vlan needs the YANG modules Cisco-IOS-XE-vlan`),
+			},
+			{
+				Config: testAccExampleResourceConfig("iosxe_vrf"),
+				Check:  testAccCheckMockExists(srv, "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR"),
+			},
+		},
+	})
+}
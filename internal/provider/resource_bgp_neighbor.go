@@ -3,13 +3,49 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/poroping/go-ios-xe-sdk/models"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
 )
 
+var bgpNeighborLeaves = map[string]string{
+	"description":             "description",
+	"disable_connected_check": "disable-connected-check",
+	"ebgp_multihop":           "ebgp-multihop",
+	"local_as":                "local-as",
+	"password":                "password",
+	"shutdown":                "shutdown",
+	"timers":                  "timers",
+	"ttl_security":            "ttl-security",
+	"update_source":           "update-source",
+}
+
+// bgpPeerGroupMemberLeaves are the leaves of neighbors only, not of peer groups.
+var bgpPeerGroupMemberLeaves = map[string]string{
+	"peer_group": "peer-group",
+}
+
+var bgpNeighborConfigLeaves = map[string]string{
+	"activate":               "activate",
+	"allowas_in":             "allowas-in",
+	"default_originate":      "default-originate",
+	"maximum_prefix":         "maximum-prefix",
+	"next_hop_self":          "next-hop-self",
+	"remove_private_as":      "remove-private-as",
+	"route_reflector_client": "route-reflector-client",
+	"send_community":         "send-community",
+	"soft_reconfiguration":   "soft-reconfiguration",
+}
+
 func resourceBgpNeighbor() *schema.Resource {
 	return &schema.Resource{
 		Description: "Manage a BGP neighbor.",
@@ -19,286 +55,722 @@ func resourceBgpNeighbor() *schema.Resource {
 		UpdateContext: resourceBgpNeighborUpdate,
 		DeleteContext: resourceBgpNeighborDelete,
 
-		Schema: map[string]*schema.Schema{
-			"activate": {
-				Description: "Activate BGP neighbor.",
-				Type:        schema.TypeBool,
-				Optional:    true,
-				Default:     true,
-			},
-			"as": {
-				Description: "Autonomous system number.",
-				Type:        schema.TypeInt,
-				Required:    true,
-				ForceNew:    true,
-			},
-			// "cluster_id": {
-			// 	Description: "Cluster ID.",
-			// 	Type:        schema.TypeString,
-			// 	Optional:    true,
-			// },
-			"default_originate": {
-				Description: "Originate default route.",
-				Type:        schema.TypeBool,
-				Computed:    true,
-				Optional:    true,
-				Default:     nil,
-			},
-			"description": {
-				Description: "Description.",
-				Type:        schema.TypeString,
-				Optional:    true,
-			},
-			"disable_connected_check": {
-				Description: "Whatever this means.",
-				Type:        schema.TypeBool,
-				Computed:    true,
-				Optional:    true,
-				Default:     nil,
-			},
-			"ebgp_multihop": {
-				Description: "EBG multi-hop.",
-				Type:        schema.TypeInt,
-				Optional:    true,
-			},
-			"ip": {
-				Description:  "Neighbor IP.",
-				Type:         schema.TypeString,
-				Required:     true,
-				ForceNew:     true,
-				ValidateFunc: validation.IsIPAddress,
-			},
-			"local_as": {
-				Description: "Local AS.",
-				Type:        schema.TypeInt,
-				Optional:    true,
-			},
-			"prefix_list": {
-				Description: "Prefix-list settings.",
-				Type:        schema.TypeList,
-				Optional:    true,
-				MaxItems:    2,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"direction": {
-							Description:  "Direction of prefix-list application.",
-							Type:         schema.TypeString,
-							Required:     true,
-							ValidateFunc: validation.StringInSlice([]string{"in", "out"}, false),
-						},
-						"name": {
-							Description: "Prefix-list name.",
-							Type:        schema.TypeString,
-							Required:    true,
-						},
+		CustomizeDiff: resourceBgpNeighborCustomizeDiff,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateDevice(resourceBgpNeighborImport),
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		Schema: resourceBgpNeighborSchema(),
+	}
+}
+
+func resourceBgpNeighborSchema() map[string]*schema.Schema {
+	s := map[string]*schema.Schema{
+		"device": deviceSchema(),
+		"address_family": {
+			Description:  "Address family the neighbor is activated in, one of `ipv4-unicast`, `ipv6-unicast`, `vpnv4-unicast`, `vpnv6-unicast` and `l2vpn-evpn`. Only `ipv4-unicast` and `ipv6-unicast` are available in VRFs. Defaults to the unicast address family of `ip`.",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			ForceNew:     true,
+			ValidateFunc: validation.StringInSlice(bgpAddressFamilyNames(), false),
+		},
+		"as": {
+			Description: "Autonomous system number.",
+			Type:        schema.TypeInt,
+			Required:    true,
+			ForceNew:    true,
+		},
+		// "cluster_id": {
+		// 	Description: "Cluster ID.",
+		// 	Type:        schema.TypeString,
+		// 	Optional:    true,
+		// },
+		"ip": {
+			Description:      "Neighbor IPv4 or IPv6 address.",
+			Type:             schema.TypeString,
+			Required:         true,
+			ForceNew:         true,
+			ValidateFunc:     validation.IsIPAddress,
+			DiffSuppressFunc: suppressEquivalentIP,
+		},
+		"peer_group": {
+			Description: "Peer group the neighbor is a member of.",
+			Type:        schema.TypeString,
+			Optional:    true,
+		},
+		"vrf": {
+			Description: "VRF, the neighbor is placed in the address family of the VRF. The global table if unset.",
+			Type:        schema.TypeString,
+			Optional:    true,
+			ForceNew:    true,
+			Default:     "",
+		},
+	}
+	for k, v := range bgpNeighborSessionSchema() {
+		s[k] = v
+	}
+	for k, v := range bgpNeighborPolicySchema() {
+		s[k] = v
+	}
+	s["remote_as"].AtLeastOneOf = []string{"remote_as", "peer_group"}
+
+	return s
+}
+
+// bgpNeighborSessionSchema returns the settings of neighbors and peer groups
+// under router bgp.
+func bgpNeighborSessionSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"description": {
+			Description: "Description.",
+			Type:        schema.TypeString,
+			Optional:    true,
+		},
+		"disable_connected_check": {
+			Description: "Whatever this means.",
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Optional:    true,
+			Default:     nil,
+		},
+		"ebgp_multihop": {
+			Description:   "EBG multi-hop.",
+			Type:          schema.TypeInt,
+			Optional:      true,
+			ConflictsWith: []string{"ttl_security"},
+		},
+		"local_as": {
+			Description: "Local AS.",
+			Type:        schema.TypeInt,
+			Optional:    true,
+		},
+		"password": {
+			Description: "MD5 password of the session. The device only returns it encrypted, so changes made on the device are detected but not the value.",
+			Type:        schema.TypeString,
+			Optional:    true,
+			Sensitive:   true,
+		},
+		"remote_as": {
+			Description: "Remote AS.",
+			Type:        schema.TypeInt,
+			Optional:    true,
+		},
+		"shutdown": {
+			Description: "Shutdown.",
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Optional:    true,
+			Default:     nil,
+		},
+		"timers": {
+			Description: "BGP timers.",
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"keepalive_interval": {
+						Description: "Keepalive interval.",
+						Type:        schema.TypeInt,
+						Required:    true,
+					},
+					"holdtime": {
+						Description: "Hold down time.",
+						Type:        schema.TypeInt,
+						Required:    true,
+					},
+					"minimum_neighbor_hold": {
+						Description: "Min hold time from neighbor.",
+						Type:        schema.TypeInt,
+						Optional:    true,
 					},
 				},
 			},
-			"remote_as": {
-				Description: "Remote AS.",
-				Type:        schema.TypeInt,
-				Required:    true,
-			},
-			"remove_private_as": {
-				Description: "Remove private AS.",
-				Type:        schema.TypeBool,
-				Computed:    true,
-				Optional:    true,
-				Default:     nil,
-			},
-			"shutdown": {
-				Description: "Shutdown.",
-				Type:        schema.TypeBool,
-				Computed:    true,
-				Optional:    true,
-				Default:     nil,
-			},
-			"soft_reconfiguration": {
-				Description: "Soft reconfiguration.",
-				Type:        schema.TypeString,
-				Optional:    true,
+		},
+		"ttl_security": {
+			Description:   "Accept packets from at most this many hops away only.",
+			Type:          schema.TypeInt,
+			Optional:      true,
+			ValidateFunc:  validation.IntBetween(1, 254),
+			ConflictsWith: []string{"ebgp_multihop"},
+		},
+		"update_source": {
+			Description:  "Interface the session is sourced from, e.g. `Loopback0`.",
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.StringMatch(interfaceNameRegexp, "must be an interface type followed by its number, e.g. Loopback0"),
+		},
+	}
+}
+
+// bgpNeighborPolicySchema returns the settings of neighbors and peer groups
+// in an address family.
+func bgpNeighborPolicySchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"activate": {
+			Description: "Activate BGP neighbor.",
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+		},
+		"allowas_in": {
+			Description:  "Accept routes with the local AS in the AS path up to this many times.",
+			Type:         schema.TypeInt,
+			Optional:     true,
+			ValidateFunc: validation.IntBetween(1, 10),
+		},
+		"default_originate": {
+			Description: "Originate default route.",
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Optional:    true,
+			Default:     nil,
+		},
+		"maximum_prefix": {
+			Description: "Maximum number of prefixes accepted from the neighbor.",
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"limit": {
+						Description:  "Maximum number of prefixes.",
+						Type:         schema.TypeInt,
+						Required:     true,
+						ValidateFunc: validation.IntAtLeast(1),
+					},
+					"threshold": {
+						Description:  "Percentage of the limit at which to warn.",
+						Type:         schema.TypeInt,
+						Optional:     true,
+						ValidateFunc: validation.IntBetween(1, 100),
+					},
+					"warning_only": {
+						Description: "Only warn when the limit is exceeded instead of closing the session.",
+						Type:        schema.TypeBool,
+						Optional:    true,
+					},
+				},
 			},
-			"timers": {
-				Description: "BGP timers.",
-				Type:        schema.TypeList,
-				Optional:    true,
-				MaxItems:    1,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"keepalive_interval": {
-							Description: "Keepalive interval.",
-							Type:        schema.TypeInt,
-							Required:    true,
-						},
-						"holdtime": {
-							Description: "Hold down time.",
-							Type:        schema.TypeInt,
-							Required:    true,
-						},
-						"minimum_neighbor_hold": {
-							Description: "Min hold time from neighbor.",
-							Type:        schema.TypeInt,
-							Optional:    true,
-						},
+		},
+		"next_hop_self": {
+			Description: "Advertise the local address as next hop.",
+			Type:        schema.TypeBool,
+			Optional:    true,
+		},
+		"prefix_list": {
+			Description: "Prefix-list settings.",
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    2,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"direction": {
+						Description:  "Direction of prefix-list application.",
+						Type:         schema.TypeString,
+						Required:     true,
+						ValidateFunc: validation.StringInSlice([]string{"in", "out"}, false),
+					},
+					"name": {
+						Description: "Prefix-list name.",
+						Type:        schema.TypeString,
+						Required:    true,
 					},
 				},
 			},
-			// "update_source": {
-			// 	Description: "Update source interface.",
-			// 	Type:        schema.TypeString,
-			// 	Optional:    true,
-			// },
-			"vrf": {
-				Description: "VRF.",
-				Type:        schema.TypeString,
-				Optional:    true,
-				Default:     "",
+		},
+		"remove_private_as": {
+			Description: "Remove private AS.",
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Optional:    true,
+			Default:     nil,
+		},
+		"route_map": {
+			Description: "Route-map settings.",
+			Type:        schema.TypeList,
+			Optional:    true,
+			MaxItems:    2,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"direction": {
+						Description:  "Direction of route-map application.",
+						Type:         schema.TypeString,
+						Required:     true,
+						ValidateFunc: validation.StringInSlice([]string{"in", "out"}, false),
+					},
+					"name": {
+						Description: "Route-map name.",
+						Type:        schema.TypeString,
+						Required:    true,
+					},
+				},
 			},
 		},
+		"route_reflector_client": {
+			Description: "Configure the neighbor as route reflector client.",
+			Type:        schema.TypeBool,
+			Optional:    true,
+		},
+		"send_community": {
+			Description:  "Communities sent to the neighbor, one of `standard`, `extended` and `both`.",
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.StringInSlice([]string{"standard", "extended", "both"}, false),
+		},
+		"soft_reconfiguration": {
+			Description: "Soft reconfiguration.",
+			Type:        schema.TypeString,
+			Optional:    true,
+		},
 	}
 }
 
-func resourceBgpNeighborCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
-	id := d.Get("ip").(string)
+func resourceBgpNeighborCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	vrf := d.Get("vrf").(string)
+	af := d.Get("address_family").(string)
+	if af == "" && d.NewValueKnown("ip") {
+		af = bgpNeighborDefaultAddressFamily(d.Get("ip").(string))
+		if err := d.SetNew("address_family", af); err != nil {
+			return err
+		}
+	}
+	if vrf == "" || !d.NewValueKnown("address_family") {
+		return nil
+	}
+	for _, f := range bgpVRFAddressFamilies {
+		if af == f {
+			return nil
+		}
+	}
 
-	as := d.Get("as").(int)
+	return fmt.Errorf("address family %s is not available in VRFs, only %s are", af, strings.Join(bgpVRFAddressFamilies, " and "))
+}
 
-	neighbor := models.BgpNeighbor{}
-	neighbor.Neighbor.ID = id
-	neighbor.Neighbor.ASN = as
+func resourceBgpNeighborCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	// create neighbor
-	getCreateUpdateBgpNeighborObject(d, &neighbor)
+	id := normalizeIP(d.Get("ip").(string))
 
-	err := client.CreateBgpNeighbor(neighbor)
+	as := d.Get("as").(int)
+	vrf := d.Get("vrf").(string)
+	af := d.Get("address_family").(string)
+	if af == "" {
+		af = bgpNeighborDefaultAddressFamily(id)
+	}
 
-	if err != nil {
-		return diag.Errorf("error creating BgpNeighbor. %s", err)
+	// create neighbor, in VRFs it only exists in the address family
+	if vrf == "" {
+		err = putBgpNeighbor(ctx, client, d, as, id)
+
+		if err != nil {
+			return diag.Errorf("error creating BgpNeighbor. %s", err)
+		}
 	}
 
 	// create neighbor config
-	neighborConf := models.BgpNeighborConfig{}
-	neighborConf.NeighborConfig.ID = id
-	neighborConf.NeighborConfig.ASN = as
-	neighborConf.NeighborConfig.AddressFamilyType = "ipv4"
-	getCreateUpdateBgpNeighborConfigObject(d, &neighborConf)
-
-	err = client.CreateBgpNeighborConfig(neighborConf)
+	err = updateBgpNeighborAddressFamily(ctx, client, d, as, vrf, af, id)
 
 	if err != nil {
 		return diag.Errorf("error creating BgpNeighborConfig. %s", err)
 	}
 
-	d.SetId(id)
+	d.SetId(bgpNeighborID(as, vrf, af, id))
 
 	return resourceBgpNeighborRead(ctx, d, meta)
 }
 
 func resourceBgpNeighborRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
-	id := d.Get("ip").(string)
-
-	as := d.Get("as").(int)
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	neighbor := models.BgpNeighbor{}
-	neighbor.Neighbor.ID = id
-	neighbor.Neighbor.ASN = as
+	// state from before composite IDs only holds the neighbor IP
+	if !strings.Contains(d.Id(), "/") {
+		d.SetId(fmt.Sprintf("%d/%s/%s", d.Get("as").(int), d.Get("vrf").(string), d.Id()))
+	}
 
-	// read neighbor
-	resp, err := client.ReadBgpNeighbor(neighbor)
+	as, vrf, af, id, err := parseBgpNeighborID(d.Id())
 
 	if err != nil {
-		return diag.Errorf("error retrieving BgpNeighbor. %s", err)
+		return diag.FromErr(err)
 	}
 
-	resourceSetBgpNeighbor(d, resp)
+	// IDs from before address families are rewritten
+	d.SetId(bgpNeighborID(as, vrf, af, id))
+
+	// read neighbor
+	if vrf == "" {
+		body, err := readBgp(ctx, client, models.BgpNeighborPath(as, id))
+
+		if err != nil {
+			if restconf.IsNotFound(err) {
+				log.Printf("[WARN] BgpNeighbor %s not found, removing from state", d.Id())
+				d.SetId("")
+				return nil
+			}
+			return diag.Errorf("error retrieving BgpNeighbor. %s", err)
+		}
+
+		entry, err := resourceSetBgpNeighborBody(d, body)
+		if err != nil {
+			return diag.Errorf("error decoding BgpNeighbor. %s", err)
+		}
+		d.Set("peer_group", bgpNeighborPeerGroup(entry))
+	}
 
 	// read neighbor config
-	neighborConf := models.BgpNeighborConfig{}
-	neighborConf.NeighborConfig.ID = id
-	neighborConf.NeighborConfig.ASN = as
-	neighborConf.NeighborConfig.AddressFamilyType = "ipv4"
-	// getCreateUpdateBgpNeighborConfigObject(d, &neighborConf)
+	resp := &models.BgpNeighborConfig{}
+	entry := map[string]interface{}{}
 
-	resp2, err := client.ReadBgpNeighborConfig(neighborConf)
+	body, err := readBgp(ctx, client, bgpNeighborAddressFamilyPath(as, vrf, af, id))
 
-	if err != nil {
+	switch {
+	case restconf.IsNotFound(err) && vrf != "":
+		log.Printf("[WARN] BgpNeighbor %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	case restconf.IsNotFound(err):
+		// neighbor exists but has no address-family config, treat as unset
+	case err != nil:
 		return diag.Errorf("error retrieving BgpNeighborConfig. %s", err)
+	default:
+		if err := json.Unmarshal(body, resp); err != nil {
+			return diag.Errorf("error decoding BgpNeighborConfig. %s", err)
+		}
+		if entry, err = bgpEntry(body); err != nil {
+			return diag.Errorf("error decoding BgpNeighborConfig. %s", err)
+		}
+		if vrf != "" {
+			if _, err := resourceSetBgpNeighborBody(d, body); err != nil {
+				return diag.Errorf("error decoding BgpNeighbor. %s", err)
+			}
+			d.Set("peer_group", bgpNeighborPeerGroup(entry))
+		}
 	}
 
-	resourceSetBgpNeighborConfig(d, resp2)
+	resourceSetBgpNeighborConfig(d, resp)
+	flattenBgpNeighborPolicy(d, entry)
 
-	d.SetId(id)
+	d.Set("as", as)
+	d.Set("ip", id)
+	d.Set("vrf", vrf)
+	d.Set("address_family", af)
 
 	return nil
 }
 
 func resourceBgpNeighborUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
-	id := d.Get("ip").(string)
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id := normalizeIP(d.Get("ip").(string))
 
 	as := d.Get("as").(int)
+	vrf := d.Get("vrf").(string)
+	af := d.Get("address_family").(string)
 
-	neighbor := models.BgpNeighbor{}
-	neighbor.Neighbor.ID = id
-	neighbor.Neighbor.ASN = as
+	leaves := clearedBgpNeighborConfigLeaves(d)
+	neighborLeaves := append(clearedLeaves(d, bgpNeighborLeaves), clearedLeaves(d, bgpPeerGroupMemberLeaves)...)
 
-	// update neighbor
-	getCreateUpdateBgpNeighborObject(d, &neighbor)
+	// update neighbor, unset leaves have to be removed explicitly
+	if vrf == "" {
+		err = deleteBgpLeaves(ctx, client, models.BgpNeighborPath(as, id), neighborLeaves)
 
-	err := client.CreateBgpNeighbor(neighbor)
+		if err != nil {
+			return diag.Errorf("error updating BgpNeighbor. %s", err)
+		}
 
-	if err != nil {
-		return diag.Errorf("error updating BgpNeighbor. %s", err)
+		err = putBgpNeighbor(ctx, client, d, as, id)
+
+		if err != nil {
+			return diag.Errorf("error updating BgpNeighbor. %s", err)
+		}
+	} else {
+		leaves = append(leaves, neighborLeaves...)
 	}
 
 	// update neighbor config
-	neighborConf := models.BgpNeighborConfig{}
-	neighborConf.NeighborConfig.ID = id
-	neighborConf.NeighborConfig.ASN = as
-	neighborConf.NeighborConfig.AddressFamilyType = "ipv4"
+	err = deleteBgpLeaves(ctx, client, bgpNeighborAddressFamilyPath(as, vrf, af, id), leaves)
 
-	getCreateUpdateBgpNeighborConfigObject(d, &neighborConf)
+	if err != nil {
+		return diag.Errorf("error updating BgpNeighborConfig. %s", err)
+	}
 
-	// err = client.UpdateBgpNeighborConfig(as, neighborConf)
-	err = client.CreateBgpNeighborConfig(neighborConf)
+	err = updateBgpNeighborAddressFamily(ctx, client, d, as, vrf, af, id)
 
 	if err != nil {
 		return diag.Errorf("error updating BgpNeighborConfig. %s", err)
 	}
 
-	d.SetId(id)
-
 	return resourceBgpNeighborRead(ctx, d, meta)
 }
 
 func resourceBgpNeighborDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
-	id := d.Get("ip").(string)
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id := normalizeIP(d.Get("ip").(string))
 
 	as := d.Get("as").(int)
+	vrf := d.Get("vrf").(string)
+	af := d.Get("address_family").(string)
+
+	// delete neighbor config
+	err = deleteBgp(ctx, client, bgpNeighborAddressFamilyPath(as, vrf, af, id))
+
+	if err != nil {
+		return diag.Errorf("error deleting BgpNeighborConfig. %s", err)
+	}
 
+	// delete neighbor along with its last address family, which removes all
+	// its child config
+	if vrf == "" {
+		families, err := bgpNeighborAddressFamilies(ctx, client, as, id)
+
+		if err != nil {
+			return diag.Errorf("error deleting BgpNeighbor. %s", err)
+		}
+
+		if len(families) == 0 {
+			err = deleteBgp(ctx, client, models.BgpNeighborPath(as, id))
+
+			if err != nil {
+				return diag.Errorf("error deleting BgpNeighbor. %s", err)
+			}
+		}
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+func resourceBgpNeighborImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	as, vrf, af, ip, err := parseBgpNeighborID(d.Id())
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetId(bgpNeighborID(as, vrf, af, ip))
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// bgpNeighborID builds the "<as>/<vrf>/<address_family>/<ip>" resource ID, vrf
+// is empty for the global table.
+func bgpNeighborID(as int, vrf string, af string, ip string) string {
+	return fmt.Sprintf("%d/%s/%s/%s", as, vrf, af, ip)
+}
+
+// parseBgpNeighborID accepts "<as>/<vrf>/<address_family>/<ip>", and for
+// neighbors in the unicast address family of their IP "<as>/<vrf>/<ip>" or
+// "<as>/<ip>" for neighbors in the global table.
+func parseBgpNeighborID(id string) (int, string, string, string, error) {
+	parts := strings.Split(id, "/")
+	switch len(parts) {
+	case 2:
+		parts = []string{parts[0], "", bgpNeighborDefaultAddressFamily(parts[1]), parts[1]}
+	case 3:
+		parts = []string{parts[0], parts[1], bgpNeighborDefaultAddressFamily(parts[2]), parts[2]}
+	}
+	if len(parts) != 4 || parts[3] == "" {
+		return 0, "", "", "", fmt.Errorf("unexpected format of ID %q, expected <as>/<vrf>/<address_family>/<ip> or <as>/<ip>", id)
+	}
+	as, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", "", "", fmt.Errorf("unable to parse AS from ID %q. %s", id, err)
+	}
+	if _, ok := bgpAddressFamilies[parts[2]]; !ok {
+		return 0, "", "", "", fmt.Errorf("unknown address family %q in ID %q, expected one of %s", parts[2], id, strings.Join(bgpAddressFamilyNames(), ", "))
+	}
+
+	return as, parts[1], parts[2], normalizeIP(parts[3]), nil
+}
+
+// bgpNeighborDefaultAddressFamily returns the unicast address family of ip.
+func bgpNeighborDefaultAddressFamily(ip string) string {
+	if strings.Contains(ip, ":") {
+		return "ipv6-unicast"
+	}
+	return "ipv4-unicast"
+}
+
+// normalizeIP returns ip in the form the device reports it, IPv6 addresses
+// compressed and in lower case.
+func normalizeIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	// keep IPv4-mapped IPv6 addresses in the IPv6 notation
+	if parsed == nil || (parsed.To4() != nil && strings.Contains(ip, ":")) {
+		return ip
+	}
+	return parsed.String()
+}
+
+// suppressEquivalentIP hides diffs between notations of the same IP address.
+func suppressEquivalentIP(k, old, new string, d *schema.ResourceData) bool {
+	return normalizeIP(old) == normalizeIP(new)
+}
+
+// updateBgpNeighborAddressFamily merges the neighbor config into its entry
+// in the address family af of vrf, which in VRFs also holds the config of the
+// neighbor itself.
+func updateBgpNeighborAddressFamily(ctx context.Context, c *restconf.Client, d *schema.ResourceData, as int, vrf string, af string, id string) error {
+	entry, err := bgpNeighborConfigEntry(d, id)
+	if err != nil {
+		return err
+	}
+
+	if vrf != "" {
+		n, err := bgpNeighborEntry(d, id)
+		if err != nil {
+			return err
+		}
+		for k, v := range n {
+			entry[k] = v
+		}
+	}
+
+	return patchBgpNeighborAddressFamily(ctx, c, as, vrf, af, entry)
+}
+
+// patchBgpNeighborAddressFamily merges the neighbor entry into the address
+// family af of vrf.
+func patchBgpNeighborAddressFamily(ctx context.Context, c *restconf.Client, as int, vrf string, af string, entry map[string]interface{}) error {
+	body, err := bgpAddressFamilyBody(as, vrf, af, map[string]interface{}{"neighbor": []interface{}{entry}})
+	if err != nil {
+		return err
+	}
+
+	return patchBgp(ctx, c, as, body)
+}
+
+// bgpNeighborEntry returns the neighbor entry of the neighbor settings,
+// including its peer group.
+func bgpNeighborEntry(d *schema.ResourceData, id string) (map[string]interface{}, error) {
+	entry, err := bgpNeighborSessionEntry(d, id)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := d.GetOk("peer_group"); ok {
+		entry["peer-group"] = map[string]interface{}{"peer-group-name": v.(string)}
+	}
+
+	return entry, nil
+}
+
+// bgpNeighborSessionEntry returns the neighbor entry id of the settings shared
+// by neighbors and peer groups under router bgp.
+func bgpNeighborSessionEntry(d *schema.ResourceData, id string) (map[string]interface{}, error) {
 	neighbor := models.BgpNeighbor{}
 	neighbor.Neighbor.ID = id
-	neighbor.Neighbor.ASN = as
 
-	// delete neighbor (we can just remove parent here it will remove all child config)
-	err := client.DeleteBgpNeighbor(neighbor)
+	getCreateUpdateBgpNeighborObject(d, &neighbor)
 
+	entry, err := jsonContent(neighbor)
 	if err != nil {
-		return diag.Errorf("error deleting BgpNeighbor. %s", err)
+		return nil, err
+	}
+	if err := expandBgpNeighborSession(d, entry); err != nil {
+		return nil, err
 	}
 
-	d.SetId("")
+	return entry, nil
+}
 
-	return nil
+// bgpNeighborConfigEntry returns the neighbor entry id of the settings shared
+// by neighbors and peer groups in an address family.
+func bgpNeighborConfigEntry(d *schema.ResourceData, id string) (map[string]interface{}, error) {
+	neighborConf := models.BgpNeighborConfig{}
+	neighborConf.NeighborConfig.ID = id
+
+	getCreateUpdateBgpNeighborConfigObject(d, &neighborConf)
+
+	entry, err := jsonContent(neighborConf)
+	if err != nil {
+		return nil, err
+	}
+	expandBgpNeighborPolicy(d, entry)
+
+	return entry, nil
+}
+
+// clearedBgpNeighborConfigLeaves returns the address family leaves and list
+// entries of neighbors and peer groups removed in this update.
+func clearedBgpNeighborConfigLeaves(d *schema.ResourceData) []string {
+	leaves := clearedLeaves(d, bgpNeighborConfigLeaves)
+	for _, direction := range removedListKeys(d, "prefix_list", "direction") {
+		leaves = append(leaves, fmt.Sprintf("prefix-list=%s", direction))
+	}
+	for _, direction := range removedListKeys(d, "route_map", "direction") {
+		leaves = append(leaves, fmt.Sprintf("route-map=%s", direction))
+	}
+
+	return leaves
+}
+
+// putBgpNeighbor replaces the neighbor entry of the global table.
+func putBgpNeighbor(ctx context.Context, c *restconf.Client, d *schema.ResourceData, as int, id string) error {
+	entry, err := bgpNeighborEntry(d, id)
+	if err != nil {
+		return err
+	}
+
+	return putBgpNeighborEntry(ctx, c, as, id, entry)
+}
+
+// putBgpNeighborEntry replaces the neighbor entry id of the global table.
+func putBgpNeighborEntry(ctx context.Context, c *restconf.Client, as int, id string, entry map[string]interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"Cisco-IOS-XE-bgp:neighbor": entry})
+	if err != nil {
+		return err
+	}
+
+	return putBgp(ctx, c, models.BgpNeighborPath(as, id), body)
+}
+
+// bgpNeighborAddressFamilies returns the address families of the global table
+// the neighbor is configured in.
+func bgpNeighborAddressFamilies(ctx context.Context, c *restconf.Client, as int, id string) ([]string, error) {
+	families := []string{}
+	for _, af := range bgpAddressFamilyNames() {
+		_, err := readBgp(ctx, c, bgpNeighborAddressFamilyPath(as, "", af, id))
+		if restconf.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		families = append(families, af)
+	}
+
+	return families, nil
 }
 
 func resourceSetBgpNeighbor(d *schema.ResourceData, resp *models.BgpNeighbor) {
 	// d.Set("cluster_id", resp.Neighbor.ClusterID)
 	if resp.Neighbor.Description != nil {
 		d.Set("description", resp.Neighbor.Description)
+	} else {
+		d.Set("description", "")
 	}
 	if resp.Neighbor.DisableConnectedCheck != nil {
 		d.Set("disable_connected_check", true)
@@ -307,9 +779,13 @@ func resourceSetBgpNeighbor(d *schema.ResourceData, resp *models.BgpNeighbor) {
 	}
 	if resp.Neighbor.EbgpMultihop != nil {
 		d.Set("ebgp_multihop", resp.Neighbor.EbgpMultihop.MaxHop)
+	} else {
+		d.Set("ebgp_multihop", 0)
 	}
 	if resp.Neighbor.LocalAs != nil {
 		d.Set("local_as", resp.Neighbor.LocalAs.AsNo)
+	} else {
+		d.Set("local_as", 0)
 	}
 	d.Set("remote_as", resp.Neighbor.RemoteAs)
 	if resp.Neighbor.Shutdown != nil {
@@ -318,9 +794,85 @@ func resourceSetBgpNeighbor(d *schema.ResourceData, resp *models.BgpNeighbor) {
 		d.Set("shutdown", false)
 	}
 	d.Set("timers", flattenBgpNeighborTimers(resp.Neighbor.Timers))
-	// if resp.Neighbor.UpdateSource != nil {
-	// 	d.Set("update_source", "TOBEIMPLEMENTED") // do something here cause interface name is variable key
-	// }
+}
+
+// resourceSetBgpNeighborBody sets the neighbor settings from the body of a GET
+// of a neighbor entry and returns the entry.
+func resourceSetBgpNeighborBody(d *schema.ResourceData, body []byte) (map[string]interface{}, error) {
+	neighbor := &models.BgpNeighbor{}
+	if err := json.Unmarshal(body, neighbor); err != nil {
+		return nil, err
+	}
+	entry, err := bgpEntry(body)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceSetBgpNeighbor(d, neighbor)
+	flattenBgpNeighborSession(d, entry)
+
+	return entry, nil
+}
+
+// bgpNeighborPeerGroup returns the peer group the neighbor entry is a member of.
+func bgpNeighborPeerGroup(entry map[string]interface{}) string {
+	name, _ := jsonMember(jsonObject(entry, "peer-group"), "peer-group-name")
+	return stringValue(name)
+}
+
+// flattenBgpNeighborSession sets the neighbor settings the SDK model lacks.
+func flattenBgpNeighborSession(d *schema.ResourceData, entry map[string]interface{}) {
+	d.Set("update_source", flattenInterfaceName(jsonObject(jsonObject(entry, "update-source"), "interface")))
+	// the device returns the password encrypted, keep the configured one
+	if _, ok := jsonMember(entry, "password"); ok {
+		d.Set("password", d.Get("password"))
+	} else {
+		d.Set("password", "")
+	}
+	hops, _ := jsonMember(jsonObject(entry, "ttl-security"), "hops")
+	d.Set("ttl_security", intValue(hops))
+}
+
+// flattenBgpNeighborPolicy sets the address family settings the SDK model lacks.
+func flattenBgpNeighborPolicy(d *schema.ResourceData, entry map[string]interface{}) {
+	count, _ := jsonMember(jsonObject(entry, "allowas-in"), "as-number")
+	d.Set("allowas_in", intValue(count))
+
+	maximumPrefix := []map[string]interface{}{}
+	if m := jsonObject(entry, "maximum-prefix"); m != nil {
+		limit, _ := jsonMember(m, "max-prefix-no")
+		threshold, _ := jsonMember(m, "threshold")
+		_, warningOnly := jsonMember(m, "warning-only")
+		maximumPrefix = append(maximumPrefix, map[string]interface{}{
+			"limit":        intValue(limit),
+			"threshold":    intValue(threshold),
+			"warning_only": warningOnly,
+		})
+	}
+	d.Set("maximum_prefix", maximumPrefix)
+
+	_, nextHopSelf := jsonMember(entry, "next-hop-self")
+	d.Set("next_hop_self", nextHopSelf)
+
+	routeMaps := []map[string]interface{}{}
+	list, _ := jsonMember(entry, "route-map")
+	entries, _ := list.([]interface{})
+	for _, e := range entries {
+		m, _ := e.(map[string]interface{})
+		direction, _ := jsonMember(m, "inout")
+		name, _ := jsonMember(m, "route-map-name")
+		routeMaps = append(routeMaps, map[string]interface{}{
+			"direction": stringValue(direction),
+			"name":      stringValue(name),
+		})
+	}
+	d.Set("route_map", routeMaps)
+
+	_, routeReflectorClient := jsonMember(entry, "route-reflector-client")
+	d.Set("route_reflector_client", routeReflectorClient)
+
+	where, _ := jsonMember(jsonObject(entry, "send-community"), "send-community-where")
+	d.Set("send_community", stringValue(where))
 }
 
 func resourceSetBgpNeighborConfig(d *schema.ResourceData, resp *models.BgpNeighborConfig) {
@@ -343,6 +895,8 @@ func resourceSetBgpNeighborConfig(d *schema.ResourceData, resp *models.BgpNeighb
 	d.Set("prefix_list", flattenBgpNeighborConfigPrefixList(&resp.NeighborConfig.PrefixList))
 	if resp.NeighborConfig.SoftReconfiguration != nil {
 		d.Set("soft_reconfiguration", resp.NeighborConfig.SoftReconfiguration)
+	} else {
+		d.Set("soft_reconfiguration", "")
 	}
 }
 
@@ -351,9 +905,9 @@ func flattenBgpNeighborTimers(input *models.Timers) []map[string]interface{} {
 	if l := input; l != nil {
 		for _, v := range []models.Timers{*input} {
 			output := map[string]interface{}{}
-			output["keepalive-interval"] = v.KeepaliveInterval
+			output["keepalive_interval"] = v.KeepaliveInterval
 			output["holdtime"] = v.Holdtime
-			output["minimum-neighbor-hold"] = v.MinimumNeighborHold
+			output["minimum_neighbor_hold"] = v.MinimumNeighborHold
 			results = append(results, output)
 		}
 	}
@@ -394,12 +948,16 @@ func getCreateUpdateBgpNeighborObject(d *schema.ResourceData, m *models.BgpNeigh
 	}
 	if v, ok := d.GetOk("ebgp_multihop"); ok {
 		if i, ok := v.(int); ok {
-			m.Neighbor.EbgpMultihop.MaxHop = &i
+			m.Neighbor.EbgpMultihop = &struct {
+				MaxHop *int `json:"max-hop,omitempty"`
+			}{MaxHop: &i}
 		}
 	}
 	if v, ok := d.GetOk("local_as"); ok {
 		if i, ok := v.(int); ok {
-			m.Neighbor.LocalAs.AsNo = &i
+			m.Neighbor.LocalAs = &struct {
+				AsNo *int `json:"as-no,omitempty"`
+			}{AsNo: &i}
 		}
 	}
 	if v, ok := d.GetOk("remote_as"); ok {
@@ -419,13 +977,67 @@ func getCreateUpdateBgpNeighborObject(d *schema.ResourceData, m *models.BgpNeigh
 		o := expandBgpNeighborTimers(d, "timers")
 		m.Neighbor.Timers = o
 	}
-	// if _, ok := d.GetOk("update_source"); ok {
-	// 	o := expandBgpNeighborUpdateSource(d, "update_source")
-	// 	m.Neighbor.UpdateSource = o
-	// }
 	return m
 }
 
+// expandBgpNeighborSession adds the neighbor settings the SDK model lacks to
+// the neighbor entry.
+func expandBgpNeighborSession(d *schema.ResourceData, entry map[string]interface{}) error {
+	if v, ok := d.GetOk("update_source"); ok {
+		source, err := expandInterfaceName(v.(string))
+		if err != nil {
+			return err
+		}
+		entry["update-source"] = map[string]interface{}{"interface": source}
+	}
+	if v, ok := d.GetOk("password"); ok {
+		entry["password"] = map[string]interface{}{"enctype": 0, "text": v.(string)}
+	}
+	if v, ok := d.GetOk("ttl_security"); ok {
+		entry["ttl-security"] = map[string]interface{}{"hops": v.(int)}
+	}
+	return nil
+}
+
+// expandBgpNeighborPolicy adds the address family settings the SDK model
+// lacks to the neighbor entry.
+func expandBgpNeighborPolicy(d *schema.ResourceData, entry map[string]interface{}) {
+	if v, ok := d.GetOk("allowas_in"); ok {
+		entry["allowas-in"] = map[string]interface{}{"as-number": v.(int)}
+	}
+	if l := d.Get("maximum_prefix").([]interface{}); len(l) > 0 && l[0] != nil {
+		m := l[0].(map[string]interface{})
+		maximumPrefix := map[string]interface{}{"max-prefix-no": m["limit"].(int)}
+		if t := m["threshold"].(int); t > 0 {
+			maximumPrefix["threshold"] = t
+		}
+		if m["warning_only"].(bool) {
+			maximumPrefix["warning-only"] = []interface{}{nil}
+		}
+		entry["maximum-prefix"] = maximumPrefix
+	}
+	if d.Get("next_hop_self").(bool) {
+		entry["next-hop-self"] = map[string]interface{}{}
+	}
+	if l := d.Get("route_map").([]interface{}); len(l) > 0 {
+		routeMaps := make([]interface{}, 0, len(l))
+		for _, v := range l {
+			m := v.(map[string]interface{})
+			routeMaps = append(routeMaps, map[string]interface{}{
+				"inout":          m["direction"].(string),
+				"route-map-name": m["name"].(string),
+			})
+		}
+		entry["route-map"] = routeMaps
+	}
+	if d.Get("route_reflector_client").(bool) {
+		entry["route-reflector-client"] = []interface{}{nil}
+	}
+	if v, ok := d.GetOk("send_community"); ok {
+		entry["send-community"] = map[string]interface{}{"send-community-where": v.(string)}
+	}
+}
+
 func getCreateUpdateBgpNeighborConfigObject(d *schema.ResourceData, m *models.BgpNeighborConfig) *models.BgpNeighborConfig {
 	if v, ok := d.GetOk("activate"); ok {
 		if b, ok := v.(bool); ok {
@@ -442,11 +1054,6 @@ func getCreateUpdateBgpNeighborConfigObject(d *schema.ResourceData, m *models.Bg
 			}
 		}
 	}
-	if v, ok := d.GetOk("local_as"); ok {
-		if i, ok := v.(int); ok {
-			m.NeighborConfig.LocalAs.AsNo = i
-		}
-	}
 	if _, ok := d.GetOk("prefix_list"); ok {
 		o := expandBgpNeighborConfigPrefixList(d, "prefix_list")
 		m.NeighborConfig.PrefixList = *o
@@ -498,7 +1105,7 @@ func expandBgpNeighborTimers(d *schema.ResourceData, field string) *models.Timer
 		m := v.(map[string]interface{})
 		temp.KeepaliveInterval = m["keepalive_interval"].(int)
 		temp.Holdtime = m["holdtime"].(int)
-		temp.MinimumNeighborHold = m["minimum-neighbor-hold"].(int)
+		temp.MinimumNeighborHold = m["minimum_neighbor_hold"].(int)
 		r = append(r, temp)
 	}
 
@@ -1,6 +1,13 @@
 package provider
 
 import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -15,6 +22,477 @@ func TestBgpNeighbor_basic(t *testing.T) {
 		// CheckDestroy: testAccCheckExampleResourceDestroy,
 		Steps: []resource.TestStep{
 			testAccCreateResourceFromExampleStep(rName),
+			testAccImportResourceFromExampleStep(rName),
 		},
 	})
 }
+
+func TestBgpNeighbor_mock(t *testing.T) {
+	rName := "iosxe_bgp_neighbor"
+	srv := testAccMockDevice(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, "Cisco-IOS-XE-native:native/router/bgp=65420"),
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+			{
+				Config: testAccBgpNeighborUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, "Cisco-IOS-XE-native:native/router/bgp=65420"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "remote_as", "8900"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "description", "UPDATED"),
+				),
+			},
+			testAccImportResourceFromExampleStep(rName),
+		},
+	})
+}
+
+const testAccBgpNeighborUpdateConfig = `
+resource "iosxe_bgp_router" "example" {
+  as                   = 65420
+  log_neighbor_changes = true
+}
+
+resource "iosxe_bgp_neighbor" "example" {
+  as                = iosxe_bgp_router.example.as
+  ip                = "7.7.7.7"
+  remote_as         = 8900
+  description       = "UPDATED"
+  default_originate = true
+}
+`
+
+func TestBgpNeighbor_mockClearAttributes(t *testing.T) {
+	srv := testAccMockDevice(t)
+	path := "Cisco-IOS-XE-native:native/router/Cisco-IOS-XE-bgp:bgp=65420/neighbor=7.7.7.7"
+	confPath := "Cisco-IOS-XE-native:native/router/Cisco-IOS-XE-bgp:bgp=65420/address-family/no-vrf/ipv4=unicast/ipv4-unicast/neighbor=7.7.7.7"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccBgpNeighborClearConfig(`
+  description             = "totallyterraformed"
+  disable_connected_check = true
+  ebgp_multihop           = 2
+  local_as                = 100
+  shutdown                = true
+  default_originate       = true
+  remove_private_as       = true
+  soft_reconfiguration    = "inbound"
+
+  timers {
+    keepalive_interval    = 10
+    holdtime              = 30
+    minimum_neighbor_hold = 15
+  }
+
+  prefix_list {
+    direction = "in"
+    name      = "pl_test"
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "ebgp_multihop", "2"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "local_as", "100"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "timers.0.holdtime", "30"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "prefix_list.#", "1"),
+				),
+			},
+			{
+				Config: testAccBgpNeighborClearConfig(`
+  disable_connected_check = false
+  shutdown                = false
+  default_originate       = false
+  remove_private_as       = false
+  activate                = false
+`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockDeleted(srv,
+						path+"/description",
+						path+"/disable-connected-check",
+						path+"/ebgp-multihop",
+						path+"/local-as",
+						path+"/shutdown",
+						path+"/timers",
+						confPath+"/activate",
+						confPath+"/default-originate",
+						confPath+"/prefix-list=in",
+						confPath+"/remove-private-as",
+						confPath+"/soft-reconfiguration",
+					),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "description", ""),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "ebgp_multihop", "0"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "local_as", "0"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "shutdown", "false"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "activate", "false"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "timers.#", "0"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "prefix_list.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func testAccBgpNeighborClearConfig(attrs string) string {
+	return `
+resource "iosxe_bgp_router" "example" {
+  as = 65420
+}
+
+resource "iosxe_bgp_neighbor" "example" {
+  as        = iosxe_bgp_router.example.as
+  ip        = "7.7.7.7"
+  remote_as = 8899
+` + attrs + `
+}
+`
+}
+
+func TestBgpNeighbor_mockSessionAndPolicy(t *testing.T) {
+	srv := testAccMockDevice(t)
+	path := "Cisco-IOS-XE-native:native/router/Cisco-IOS-XE-bgp:bgp=65420/neighbor=7.7.7.7"
+	confPath := "Cisco-IOS-XE-native:native/router/Cisco-IOS-XE-bgp:bgp=65420/address-family/no-vrf/ipv4=unicast/ipv4-unicast/neighbor=7.7.7.7"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccBgpNeighborClearConfig("  ttl_security  = 2\n  ebgp_multihop = 2\n"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("conflicts with"),
+			},
+			{
+				Config: testAccBgpNeighborClearConfig(`
+  update_source          = "Loopback0"
+  password               = "s3cr3t"
+  peer_group             = "IBGP"
+  ttl_security           = 2
+  allowas_in             = 3
+  send_community         = "both"
+  next_hop_self          = true
+  route_reflector_client = true
+
+  maximum_prefix {
+    limit        = 1000
+    threshold    = 80
+    warning_only = true
+  }
+
+  route_map {
+    direction = "in"
+    name      = "RM_IN"
+  }
+
+  route_map {
+    direction = "out"
+    name      = "RM_OUT"
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, path+"/update-source/interface/Loopback"),
+					testAccCheckMockExists(srv, path+"/password/text"),
+					testAccCheckMockExists(srv, path+"/peer-group/peer-group-name"),
+					testAccCheckMockExists(srv, path+"/ttl-security/hops"),
+					testAccCheckMockExists(srv, confPath+"/route-map=out"),
+					testAccCheckMockExists(srv, confPath+"/maximum-prefix/max-prefix-no"),
+					testAccCheckMockExists(srv, confPath+"/send-community/send-community-where"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "update_source", "Loopback0"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "password", "s3cr3t"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "peer_group", "IBGP"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "ttl_security", "2"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "allowas_in", "3"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "send_community", "both"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "next_hop_self", "true"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "route_reflector_client", "true"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "maximum_prefix.0.limit", "1000"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "maximum_prefix.0.threshold", "80"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "maximum_prefix.0.warning_only", "true"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "route_map.#", "2"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "route_map.1.name", "RM_OUT"),
+				),
+			},
+			{
+				ResourceName:            "iosxe_bgp_neighbor.example",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"password"},
+			},
+			{
+				Config: testAccBgpNeighborClearConfig(`
+  update_source = "GigabitEthernet1/0/1"
+
+  route_map {
+    direction = "in"
+    name      = "RM_IN"
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockDeleted(srv,
+						path+"/password",
+						path+"/peer-group",
+						path+"/ttl-security",
+						confPath+"/allowas-in",
+						confPath+"/maximum-prefix",
+						confPath+"/next-hop-self",
+						confPath+"/route-map=out",
+						confPath+"/route-reflector-client",
+						confPath+"/send-community",
+					),
+					testAccCheckMockExists(srv, path+"/update-source/interface/GigabitEthernet"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "update_source", "GigabitEthernet1/0/1"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "password", ""),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "peer_group", ""),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "maximum_prefix.#", "0"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "route_map.#", "1"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "next_hop_self", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestBgpNeighbor_mockPasswordNotLogged(t *testing.T) {
+	testAccMockDevice(t)
+
+	logPath := filepath.Join(t.TempDir(), "acc.log")
+	t.Setenv("TF_LOG", "DEBUG")
+	t.Setenv("TF_ACC_LOG_PATH", logPath)
+
+	var stdlog bytes.Buffer
+	log.SetOutput(&stdlog)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	secret := "n0t-in-l0gs"
+	peerGroup := fmt.Sprintf(`
+resource "iosxe_bgp_peer_group" "example" {
+  as       = iosxe_bgp_router.example.as
+  name     = "IBGP"
+  password = %q
+}
+`, secret)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccBgpNeighborClearConfig(fmt.Sprintf("  password = %q\n", secret)) + peerGroup,
+				Check:  resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "password", secret),
+			},
+			{
+				Config: testAccBgpNeighborClearConfig(fmt.Sprintf("  password = %q\n  description = \"foo\"\n", secret)) + peerGroup,
+			},
+		},
+	})
+
+	b, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(string(b), "request_body") {
+		t.Fatalf("expected the requests to be logged")
+	}
+	if strings.Contains(string(b), secret) || strings.Contains(stdlog.String(), secret) {
+		t.Fatalf("password %q found in the logs", secret)
+	}
+}
+
+func TestBgpNeighbor_mockVRF(t *testing.T) {
+	srv := testAccMockDevice(t)
+	bgp := "Cisco-IOS-XE-native:native/router/Cisco-IOS-XE-bgp:bgp=65420"
+	vrfPath := bgp + "/address-family/with-vrf/ipv4=unicast/vrf=FOOBAR/ipv4-unicast/neighbor=10.0.0.2"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, bgp),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccBgpNeighborVRFConfig("UPDATED"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, vrfPath+"/activate"),
+					testAccCheckMockExists(srv, vrfPath+"/remote-as"),
+					testAccCheckMockDestroy(srv, bgp+"/neighbor=10.0.0.2"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "id", "65420/FOOBAR/ipv4-unicast/10.0.0.2"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "description", "UPDATED"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "remote_as", "8900"),
+				),
+			},
+			{
+				Config: testAccBgpNeighborVRFConfig(""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockDeleted(srv, vrfPath+"/description"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "description", ""),
+				),
+			},
+			{
+				ResourceName:      "iosxe_bgp_neighbor.example",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccBgpNeighborVRFConfig(description string) string {
+	return fmt.Sprintf(`
+resource "iosxe_bgp_router" "example" {
+  as = 65420
+}
+
+resource "iosxe_bgp_neighbor" "example" {
+  as          = iosxe_bgp_router.example.as
+  vrf         = "FOOBAR"
+  ip          = "10.0.0.2"
+  remote_as   = 8900
+  description = %q
+}
+`, description)
+}
+
+func TestBgpNeighbor_mockAddressFamilies(t *testing.T) {
+	srv := testAccMockDevice(t)
+	bgp := "Cisco-IOS-XE-native:native/router/Cisco-IOS-XE-bgp:bgp=65420"
+	vpnv4Path := bgp + "/address-family/no-vrf/vpnv4=unicast/vpnv4-unicast/neighbor=7.7.7.7"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, bgp),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccBgpNeighborAddressFamiliesConfig + testAccBgpNeighborVPNv4Config,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, bgp+"/neighbor=7.7.7.7"),
+					testAccCheckMockExists(srv, bgp+"/address-family/no-vrf/ipv4=unicast/ipv4-unicast/neighbor=7.7.7.7/activate"),
+					testAccCheckMockExists(srv, vpnv4Path+"/activate"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.vpnv4", "id", "65420//vpnv4-unicast/7.7.7.7"),
+				),
+			},
+			{
+				// the neighbor stays with its remaining address family
+				Config: testAccBgpNeighborAddressFamiliesConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockDestroy(srv, vpnv4Path),
+					testAccCheckMockExists(srv, bgp+"/neighbor=7.7.7.7"),
+				),
+			},
+			{
+				Config:      testAccBgpNeighborAddressFamiliesConfig + strings.Replace(testAccBgpNeighborVPNv4Config, "as ", "vrf = \"FOOBAR\"\n  as ", 1),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("address family vpnv4-unicast is not available in VRFs"),
+			},
+		},
+	})
+}
+
+const testAccBgpNeighborAddressFamiliesConfig = `
+resource "iosxe_bgp_router" "example" {
+  as = 65420
+}
+
+resource "iosxe_bgp_neighbor" "ipv4" {
+  as        = iosxe_bgp_router.example.as
+  ip        = "7.7.7.7"
+  remote_as = 8900
+}
+`
+
+const testAccBgpNeighborVPNv4Config = `
+resource "iosxe_bgp_neighbor" "vpnv4" {
+  as             = iosxe_bgp_router.example.as
+  address_family = "vpnv4-unicast"
+  ip             = "7.7.7.7"
+  remote_as      = 8900
+}
+`
+
+func TestBgpNeighbor_mockIPv6(t *testing.T) {
+	srv := testAccMockDevice(t)
+	bgp := "Cisco-IOS-XE-native:native/router/Cisco-IOS-XE-bgp:bgp=65420"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, bgp),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccBgpNeighborIPv6Config,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, bgp+"/neighbor=2001:db8::2/remote-as"),
+					testAccCheckMockExists(srv, bgp+"/address-family/no-vrf/ipv6=unicast/ipv6-unicast/neighbor=2001:db8::2/activate"),
+					testAccCheckMockDestroy(srv, bgp+"/address-family/no-vrf/ipv4=unicast"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "id", "65420//ipv6-unicast/2001:db8::2"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "address_family", "ipv6-unicast"),
+				),
+			},
+			{
+				ResourceName:      "iosxe_bgp_neighbor.example",
+				ImportState:       true,
+				ImportStateId:     "65420//ipv6-unicast/2001:0DB8:0:0:0:0:0:2",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+const testAccBgpNeighborIPv6Config = `
+resource "iosxe_bgp_router" "example" {
+  as = 65420
+}
+
+resource "iosxe_bgp_neighbor" "example" {
+  as        = iosxe_bgp_router.example.as
+  ip        = "2001:DB8:0::2"
+  remote_as = 8900
+}
+`
+
+func TestBgpNeighbor_notFound(t *testing.T) {
+	testResourceReadNotFound(t, resourceBgpNeighbor(), "65420//ipv4-unicast/7.7.7.7")
+}
+
+func TestParseBgpNeighborID(t *testing.T) {
+	cases := []struct {
+		id  string
+		as  int
+		vrf string
+		af  string
+		ip  string
+		err bool
+	}{
+		{id: "65420//ipv4-unicast/7.7.7.7", as: 65420, af: "ipv4-unicast", ip: "7.7.7.7"},
+		{id: "65420/FOOBAR/ipv6-unicast/7.7.7.7", as: 65420, vrf: "FOOBAR", af: "ipv6-unicast", ip: "7.7.7.7"},
+		{id: "65420//l2vpn-evpn/7.7.7.7", as: 65420, af: "l2vpn-evpn", ip: "7.7.7.7"},
+		{id: "65420//ipv6-unicast/2001:db8::7", as: 65420, af: "ipv6-unicast", ip: "2001:db8::7"},
+		{id: "65420//ipv6-unicast/2001:DB8:0:0::7", as: 65420, af: "ipv6-unicast", ip: "2001:db8::7"},
+		{id: "65420//7.7.7.7", as: 65420, af: "ipv4-unicast", ip: "7.7.7.7"},
+		{id: "65420/7.7.7.7", as: 65420, af: "ipv4-unicast", ip: "7.7.7.7"},
+		{id: "65420/FOOBAR/7.7.7.7", as: 65420, vrf: "FOOBAR", af: "ipv4-unicast", ip: "7.7.7.7"},
+		{id: "65420/2001:db8::1", as: 65420, af: "ipv6-unicast", ip: "2001:db8::1"},
+		{id: "65420/FOOBAR/2001:DB8::1", as: 65420, vrf: "FOOBAR", af: "ipv6-unicast", ip: "2001:db8::1"},
+		{id: "7.7.7.7", err: true},
+		{id: "notanas/7.7.7.7", err: true},
+		{id: "65420/FOOBAR/", err: true},
+		{id: "65420//ipv4-multicast/7.7.7.7", err: true},
+	}
+
+	for _, c := range cases {
+		as, vrf, af, ip, err := parseBgpNeighborID(c.id)
+		if c.err {
+			if err == nil {
+				t.Errorf("%q: expected error", c.id)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", c.id, err)
+			continue
+		}
+		if as != c.as || vrf != c.vrf || af != c.af || ip != c.ip {
+			t.Errorf("%q: got %d/%s/%s/%s", c.id, as, vrf, af, ip)
+		}
+		if as2, vrf2, af2, ip2, _ := parseBgpNeighborID(bgpNeighborID(as, vrf, af, ip)); as2 != as || vrf2 != vrf || af2 != af || ip2 != ip {
+			t.Errorf("%q: round trip gave %q", c.id, bgpNeighborID(as, vrf, af, ip))
+		}
+	}
+}
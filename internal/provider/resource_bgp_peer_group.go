@@ -0,0 +1,337 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/poroping/go-ios-xe-sdk/models"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
+)
+
+func resourceBgpPeerGroup() *schema.Resource {
+	return &schema.Resource{
+		Description: "Manage a BGP peer group.",
+
+		CreateContext: resourceBgpPeerGroupCreate,
+		ReadContext:   resourceBgpPeerGroupRead,
+		UpdateContext: resourceBgpPeerGroupUpdate,
+		DeleteContext: resourceBgpPeerGroupDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateDevice(resourceBgpPeerGroupImport),
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		Schema: resourceBgpPeerGroupSchema(),
+	}
+}
+
+func resourceBgpPeerGroupSchema() map[string]*schema.Schema {
+	s := map[string]*schema.Schema{
+		"device": deviceSchema(),
+		"address_family": {
+			Description:  "Address family the peer group is activated in, one of `ipv4-unicast`, `ipv6-unicast`, `vpnv4-unicast`, `vpnv6-unicast` and `l2vpn-evpn`.",
+			Type:         schema.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			Default:      "ipv4-unicast",
+			ValidateFunc: validation.StringInSlice(bgpAddressFamilyNames(), false),
+		},
+		"as": {
+			Description: "Autonomous system number.",
+			Type:        schema.TypeInt,
+			Required:    true,
+			ForceNew:    true,
+		},
+		"name": {
+			Description: "Peer group name.",
+			Type:        schema.TypeString,
+			Required:    true,
+			ForceNew:    true,
+			ValidateFunc: validation.All(
+				validation.StringIsNotWhiteSpace,
+				validation.StringDoesNotContainAny("/ "),
+			),
+		},
+	}
+	for k, v := range bgpNeighborSessionSchema() {
+		s[k] = v
+	}
+	for k, v := range bgpNeighborPolicySchema() {
+		s[k] = v
+	}
+
+	return s
+}
+
+func resourceBgpPeerGroupCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	name := d.Get("name").(string)
+	as := d.Get("as").(int)
+	af := d.Get("address_family").(string)
+
+	// create peer group
+	entry, err := bgpPeerGroupEntry(d, name)
+
+	if err != nil {
+		return diag.Errorf("error creating BgpPeerGroup. %s", err)
+	}
+
+	err = putBgpNeighborEntry(ctx, client, as, name, entry)
+
+	if err != nil {
+		return diag.Errorf("error creating BgpPeerGroup. %s", err)
+	}
+
+	// create peer group config
+	entry, err = bgpNeighborConfigEntry(d, name)
+
+	if err != nil {
+		return diag.Errorf("error creating BgpPeerGroupConfig. %s", err)
+	}
+
+	err = patchBgpNeighborAddressFamily(ctx, client, as, "", af, entry)
+
+	if err != nil {
+		return diag.Errorf("error creating BgpPeerGroupConfig. %s", err)
+	}
+
+	d.SetId(bgpPeerGroupID(as, af, name))
+
+	return resourceBgpPeerGroupRead(ctx, d, meta)
+}
+
+func resourceBgpPeerGroupRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	as, af, name, err := parseBgpPeerGroupID(d.Id())
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// read peer group
+	body, err := readBgp(ctx, client, models.BgpNeighborPath(as, name))
+
+	if err != nil {
+		if restconf.IsNotFound(err) {
+			log.Printf("[WARN] BgpPeerGroup %s not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error retrieving BgpPeerGroup. %s", err)
+	}
+
+	entry, err := resourceSetBgpNeighborBody(d, body)
+
+	if err != nil {
+		return diag.Errorf("error decoding BgpPeerGroup. %s", err)
+	}
+
+	if !isBgpPeerGroup(entry) {
+		log.Printf("[WARN] BgpPeerGroup %s is a neighbor, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	// read peer group config
+	resp := &models.BgpNeighborConfig{}
+	entry = map[string]interface{}{}
+
+	body, err = readBgp(ctx, client, bgpNeighborAddressFamilyPath(as, "", af, name))
+
+	switch {
+	case restconf.IsNotFound(err):
+		// peer group exists but has no address-family config, treat as unset
+	case err != nil:
+		return diag.Errorf("error retrieving BgpPeerGroupConfig. %s", err)
+	default:
+		if err := json.Unmarshal(body, resp); err != nil {
+			return diag.Errorf("error decoding BgpPeerGroupConfig. %s", err)
+		}
+		if entry, err = bgpEntry(body); err != nil {
+			return diag.Errorf("error decoding BgpPeerGroupConfig. %s", err)
+		}
+	}
+
+	resourceSetBgpNeighborConfig(d, resp)
+	flattenBgpNeighborPolicy(d, entry)
+
+	d.Set("as", as)
+	d.Set("name", name)
+	d.Set("address_family", af)
+
+	return nil
+}
+
+func resourceBgpPeerGroupUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	name := d.Get("name").(string)
+	as := d.Get("as").(int)
+	af := d.Get("address_family").(string)
+
+	// update peer group, unset leaves have to be removed explicitly
+	err = deleteBgpLeaves(ctx, client, models.BgpNeighborPath(as, name), clearedLeaves(d, bgpNeighborLeaves))
+
+	if err != nil {
+		return diag.Errorf("error updating BgpPeerGroup. %s", err)
+	}
+
+	entry, err := bgpPeerGroupEntry(d, name)
+
+	if err != nil {
+		return diag.Errorf("error updating BgpPeerGroup. %s", err)
+	}
+
+	err = putBgpNeighborEntry(ctx, client, as, name, entry)
+
+	if err != nil {
+		return diag.Errorf("error updating BgpPeerGroup. %s", err)
+	}
+
+	// update peer group config
+	err = deleteBgpLeaves(ctx, client, bgpNeighborAddressFamilyPath(as, "", af, name), clearedBgpNeighborConfigLeaves(d))
+
+	if err != nil {
+		return diag.Errorf("error updating BgpPeerGroupConfig. %s", err)
+	}
+
+	entry, err = bgpNeighborConfigEntry(d, name)
+
+	if err != nil {
+		return diag.Errorf("error updating BgpPeerGroupConfig. %s", err)
+	}
+
+	err = patchBgpNeighborAddressFamily(ctx, client, as, "", af, entry)
+
+	if err != nil {
+		return diag.Errorf("error updating BgpPeerGroupConfig. %s", err)
+	}
+
+	return resourceBgpPeerGroupRead(ctx, d, meta)
+}
+
+func resourceBgpPeerGroupDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	name := d.Get("name").(string)
+	as := d.Get("as").(int)
+	af := d.Get("address_family").(string)
+
+	// delete peer group config
+	err = deleteBgp(ctx, client, bgpNeighborAddressFamilyPath(as, "", af, name))
+
+	if err != nil {
+		return diag.Errorf("error deleting BgpPeerGroupConfig. %s", err)
+	}
+
+	// delete peer group along with its last address family
+	families, err := bgpNeighborAddressFamilies(ctx, client, as, name)
+
+	if err != nil {
+		return diag.Errorf("error deleting BgpPeerGroup. %s", err)
+	}
+
+	if len(families) == 0 {
+		err = deleteBgp(ctx, client, models.BgpNeighborPath(as, name))
+
+		if err != nil {
+			return diag.Errorf("error deleting BgpPeerGroup. %s", err)
+		}
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+func resourceBgpPeerGroupImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	as, af, name, err := parseBgpPeerGroupID(d.Id())
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetId(bgpPeerGroupID(as, af, name))
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// bgpPeerGroupEntry returns the neighbor entry defining the peer group name.
+func bgpPeerGroupEntry(d *schema.ResourceData, name string) (map[string]interface{}, error) {
+	entry, err := bgpNeighborSessionEntry(d, name)
+	if err != nil {
+		return nil, err
+	}
+	entry["peer-group"] = map[string]interface{}{}
+
+	return entry, nil
+}
+
+// isBgpPeerGroup reports whether the neighbor entry defines a peer group, in
+// which its peer-group container is empty. Members of a peer group name it.
+func isBgpPeerGroup(entry map[string]interface{}) bool {
+	v, ok := jsonMember(entry, "peer-group")
+	if !ok {
+		return false
+	}
+	if _, ok := v.([]interface{}); ok {
+		return true
+	}
+
+	return bgpNeighborPeerGroup(entry) == ""
+}
+
+// bgpPeerGroupID builds the "<as>/<address_family>/<name>" resource ID.
+func bgpPeerGroupID(as int, af string, name string) string {
+	return fmt.Sprintf("%d/%s/%s", as, af, name)
+}
+
+// parseBgpPeerGroupID accepts "<as>/<address_family>/<name>", and
+// "<as>/<name>" for ipv4-unicast peer groups.
+func parseBgpPeerGroupID(id string) (int, string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) == 2 {
+		parts = []string{parts[0], "ipv4-unicast", parts[1]}
+	}
+	if len(parts) != 3 || parts[2] == "" {
+		return 0, "", "", fmt.Errorf("unexpected format of ID %q, expected <as>/<address_family>/<name> or <as>/<name>", id)
+	}
+	as, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", "", fmt.Errorf("unable to parse AS from ID %q. %s", id, err)
+	}
+	if _, ok := bgpAddressFamilies[parts[1]]; !ok {
+		return 0, "", "", fmt.Errorf("unknown address family %q in ID %q, expected one of %s", parts[1], id, strings.Join(bgpAddressFamilyNames(), ", "))
+	}
+
+	return as, parts[1], parts[2], nil
+}
@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestBgpPeerGroup_basic(t *testing.T) {
+	rName := "iosxe_bgp_peer_group"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+			testAccImportResourceFromExampleStep(rName),
+		},
+	})
+}
+
+func TestBgpPeerGroup_mock(t *testing.T) {
+	rName := "iosxe_bgp_peer_group"
+	srv := testAccMockDevice(t)
+	bgp := "Cisco-IOS-XE-native:native/router/Cisco-IOS-XE-bgp:bgp=65420"
+	confPath := bgp + "/address-family/no-vrf/ipv4=unicast/ipv4-unicast/neighbor=IBGP"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, bgp),
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+			{
+				Config: testAccExampleResourceConfig(rName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, bgp+"/neighbor=IBGP/peer-group"),
+					testAccCheckMockExists(srv, bgp+"/neighbor=IBGP/remote-as"),
+					testAccCheckMockExists(srv, bgp+"/neighbor=IBGP/update-source/interface/Loopback"),
+					testAccCheckMockExists(srv, bgp+"/neighbor=10.255.0.2/peer-group/peer-group-name"),
+					testAccCheckMockExists(srv, confPath+"/route-map=in"),
+					resource.TestCheckResourceAttr("iosxe_bgp_peer_group.example", "id", "65420/ipv4-unicast/IBGP"),
+					resource.TestCheckResourceAttr("iosxe_bgp_peer_group.example", "update_source", "Loopback0"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "peer_group", "IBGP"),
+					resource.TestCheckResourceAttr("iosxe_bgp_neighbor.example", "remote_as", "0"),
+				),
+			},
+			{
+				Config: testAccBgpPeerGroupUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockDeleted(srv,
+						bgp+"/neighbor=IBGP/update-source",
+						confPath+"/route-map=in",
+						confPath+"/send-community",
+					),
+					resource.TestCheckResourceAttr("iosxe_bgp_peer_group.example", "password", "s3cr3t"),
+					resource.TestCheckResourceAttr("iosxe_bgp_peer_group.example", "timers.0.holdtime", "30"),
+					resource.TestCheckResourceAttr("iosxe_bgp_peer_group.example", "prefix_list.0.name", "PL_IBGP_IN"),
+					resource.TestCheckResourceAttr("iosxe_bgp_peer_group.example", "route_map.#", "0"),
+				),
+			},
+			{
+				ResourceName:            "iosxe_bgp_peer_group.example",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"password"},
+			},
+		},
+	})
+}
+
+const testAccBgpPeerGroupUpdateConfig = `
+resource "iosxe_bgp_router" "example" {
+  as = 65420
+}
+
+resource "iosxe_bgp_peer_group" "example" {
+  as        = iosxe_bgp_router.example.as
+  name      = "IBGP"
+  remote_as = 65420
+  password  = "s3cr3t"
+
+  timers {
+    keepalive_interval = 10
+    holdtime           = 30
+  }
+
+  prefix_list {
+    direction = "in"
+    name      = "PL_IBGP_IN"
+  }
+}
+`
+
+func TestBgpPeerGroup_notFound(t *testing.T) {
+	testResourceReadNotFound(t, resourceBgpPeerGroup(), "65420/ipv4-unicast/IBGP")
+}
+
+func TestParseBgpPeerGroupID(t *testing.T) {
+	cases := []struct {
+		id   string
+		as   int
+		af   string
+		name string
+		err  bool
+	}{
+		{id: "65420/ipv4-unicast/IBGP", as: 65420, af: "ipv4-unicast", name: "IBGP"},
+		{id: "65420/vpnv4-unicast/RR-CLIENTS", as: 65420, af: "vpnv4-unicast", name: "RR-CLIENTS"},
+		{id: "65420/IBGP", as: 65420, af: "ipv4-unicast", name: "IBGP"},
+		{id: "IBGP", err: true},
+		{id: "notanas/IBGP", err: true},
+		{id: "65420/ipv4-unicast/", err: true},
+		{id: "65420/ipv4-multicast/IBGP", err: true},
+	}
+
+	for _, c := range cases {
+		as, af, name, err := parseBgpPeerGroupID(c.id)
+		if c.err {
+			if err == nil {
+				t.Errorf("%q: expected error", c.id)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", c.id, err)
+			continue
+		}
+		if as != c.as || af != c.af || name != c.name {
+			t.Errorf("%q: got %d/%s/%s", c.id, as, af, name)
+		}
+	}
+}
@@ -2,13 +2,51 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/poroping/go-ios-xe-sdk/models"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
 )
 
+// bgpLeaf maps an attribute, or a field of a single block like
+// bestpath.0.med_confed, to its leaf. Empty leaves, encoded as [null], are
+// set by true booleans.
+type bgpLeaf struct {
+	attr  string
+	path  string
+	empty bool
+}
+
+// bgpRouterLeaves are the leaves of the router below its bgp entry.
+var bgpRouterLeaves = []bgpLeaf{
+	{attr: "log_neighbor_changes", path: "bgp/log-neighbor-changes"},
+	{attr: "router_id", path: "bgp/router-id/ip-id"},
+	{attr: "default_local_preference", path: "bgp/default/local-preference"},
+	{attr: "graceful_restart", path: "bgp/graceful-restart", empty: true},
+	{attr: "listen_limit", path: "bgp/listen/limit"},
+	{attr: "bestpath.0.as_path_multipath_relax", path: "bgp/bestpath/as-path/multipath-relax", empty: true},
+	{attr: "bestpath.0.med_confed", path: "bgp/bestpath/med/confed", empty: true},
+	{attr: "bestpath.0.med_missing_as_worst", path: "bgp/bestpath/med/missing-as-worst", empty: true},
+}
+
+// bgpRouterAddressFamilyLeaves are the leaves of the router below the
+// container of an address family.
+var bgpRouterAddressFamilyLeaves = []bgpLeaf{
+	{attr: "maximum_paths", path: "maximum-paths/number-of-paths"},
+	{attr: "maximum_paths_ibgp", path: "maximum-paths/ibgp/number-of-paths"},
+	{attr: "default_information_originate", path: "default-information/originate", empty: true},
+}
+
 func resourceBgpRouter() *schema.Resource {
 	return &schema.Resource{
 		Description: "Manage a BGP router AS.",
@@ -18,12 +56,50 @@ func resourceBgpRouter() *schema.Resource {
 		UpdateContext: resourceBgpRouterUpdate,
 		DeleteContext: resourceBgpRouterDelete,
 
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateDevice(schema.ImportStatePassthroughContext),
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
-			"log_neighbor_changes": {
-				Description: "Log neighbor changes.",
-				Type:        schema.TypeBool,
-				Computed:    true,
+			"device": deviceSchema(),
+			"address_family": {
+				Description: "Settings of the router per address family of the global table.",
+				Type:        schema.TypeSet,
 				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Description:  "Address family, one of `ipv4-unicast`, `ipv6-unicast`, `vpnv4-unicast`, `vpnv6-unicast` and `l2vpn-evpn`.",
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(bgpAddressFamilyNames(), false),
+						},
+						"default_information_originate": {
+							Description: "Redistribute a default route.",
+							Type:        schema.TypeBool,
+							Optional:    true,
+						},
+						"maximum_paths": {
+							Description:  "Number of paths installed for multipath.",
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntBetween(1, 32),
+						},
+						"maximum_paths_ibgp": {
+							Description:  "Number of iBGP paths installed for multipath.",
+							Type:         schema.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntBetween(1, 32),
+						},
+					},
+				},
 			},
 			"as": {
 				Description: "Autonomous system number.",
@@ -31,20 +107,131 @@ func resourceBgpRouter() *schema.Resource {
 				Required:    true,
 				ForceNew:    true,
 			},
+			"bestpath": {
+				Description: "Best path selection options.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"as_path_multipath_relax": {
+							Description: "Allow multipath over paths of different neighbor ASes of the same length.",
+							Type:        schema.TypeBool,
+							Optional:    true,
+						},
+						"med_confed": {
+							Description: "Compare MED among confederation paths.",
+							Type:        schema.TypeBool,
+							Optional:    true,
+						},
+						"med_missing_as_worst": {
+							Description: "Treat a missing MED as the worst.",
+							Type:        schema.TypeBool,
+							Optional:    true,
+						},
+					},
+				},
+			},
+			"default_local_preference": {
+				Description:  "Default local preference.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"graceful_restart": {
+				Description: "Enable graceful restart.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+			},
+			"listen_limit": {
+				Description:  "Maximum number of dynamic neighbors.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"listen_range": {
+				Description: "Ranges dynamic neighbors are accepted from.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"range": {
+							Description:  "Subnet of the neighbors, e.g. `10.0.0.0/24`.",
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.IsCIDR,
+						},
+						"peer_group": {
+							Description: "Peer group the neighbors join.",
+							Type:        schema.TypeString,
+							Required:    true,
+						},
+					},
+				},
+			},
+			"log_neighbor_changes": {
+				Description: "Log neighbor changes.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Optional:    true,
+			},
+			"router_id": {
+				Description:  "Router ID.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsIPv4Address,
+			},
+			"timers": {
+				Description: "BGP timers of all neighbors.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"keepalive_interval": {
+							Description: "Keepalive interval.",
+							Type:        schema.TypeInt,
+							Required:    true,
+						},
+						"holdtime": {
+							Description: "Hold down time.",
+							Type:        schema.TypeInt,
+							Required:    true,
+						},
+						"minimum_neighbor_hold": {
+							Description: "Min hold time from neighbor.",
+							Type:        schema.TypeInt,
+							Optional:    true,
+						},
+					},
+				},
+			},
 		},
 	}
 }
 
 func resourceBgpRouterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("as").(int)
 
-	params := models.BgpRouter{}
-	params.Bgp.ID = id
+	// unset leaves are left to the device defaults
+	entry, _ := expandBgpRouter(d, func(attr string) bool {
+		_, ok := d.GetOk(attr)
+		return ok
+	})
+	entry["id"] = id
 
-	getCreateUpdateBgpRouterObject(d, &params)
+	body, err := json.Marshal(map[string]interface{}{"Cisco-IOS-XE-bgp:bgp": entry})
+
+	if err != nil {
+		return diag.Errorf("error creating BgpRouter. %s", err)
+	}
 
-	err := client.CreateBgpRouter(params)
+	err = putBgp(ctx, client, models.BgpPath(id), body)
 
 	if err != nil {
 		return diag.Errorf("error creating BgpRouter. %s", err)
@@ -52,57 +239,106 @@ func resourceBgpRouterCreate(ctx context.Context, d *schema.ResourceData, meta i
 
 	d.SetId(strconv.Itoa(id))
 
+	err = updateBgpRouterAddressFamilies(ctx, client, d, id)
+
+	if err != nil {
+		return diag.Errorf("error creating BgpRouter. %s", err)
+	}
+
 	return resourceBgpRouterRead(ctx, d, meta)
 }
 
 func resourceBgpRouterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
-	id := d.Get("as").(int)
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	params := models.BgpRouter{}
-	params.Bgp.ID = id
+	id, err := strconv.Atoi(d.Id())
 
-	resp, err := client.ReadBgpRouter(params)
+	if err != nil {
+		return diag.Errorf("error parsing BgpRouter ID %q. %s", d.Id(), err)
+	}
+
+	body, err := readBgp(ctx, client, models.BgpPath(id))
 
 	if err != nil {
+		if restconf.IsNotFound(err) {
+			log.Printf("[WARN] BgpRouter %d not found, removing from state", id)
+			d.SetId("")
+			return nil
+		}
 		return diag.Errorf("error retrieving BgpRouter. %s", err)
 	}
 
-	resourceSetBgpRouter(d, resp)
+	entry, err := bgpEntry(body)
 
-	d.SetId(strconv.Itoa(id))
+	if err != nil {
+		return diag.Errorf("error decoding BgpRouter. %s", err)
+	}
+
+	resourceSetBgpRouter(d, entry)
+
+	d.Set("as", id)
 
 	return nil
 }
 
 func resourceBgpRouterUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("as").(int)
 
-	params := models.BgpRouter{}
-	params.Bgp.ID = id
+	// only changed leaves are written, unset ones have to be removed explicitly
+	entry, leaves := expandBgpRouter(d, d.HasChange)
 
-	getCreateUpdateBgpRouterObject(d, &params)
+	for _, r := range removedListenRanges(d) {
+		leaves = append(leaves, fmt.Sprintf("bgp/listen/range=%s", url.PathEscape(r)))
+	}
 
-	err := client.UpdateBgpRouter(params)
+	err = deleteBgpLeaves(ctx, client, models.BgpPath(id), leaves)
 
 	if err != nil {
 		return diag.Errorf("error updating BgpRouter. %s", err)
 	}
 
-	d.SetId(strconv.Itoa(id))
+	if len(entry) > 0 {
+		entry["id"] = id
+
+		body, err := json.Marshal(map[string]interface{}{"Cisco-IOS-XE-bgp:bgp": entry})
+
+		if err != nil {
+			return diag.Errorf("error updating BgpRouter. %s", err)
+		}
+
+		err = patchBgp(ctx, client, id, body)
+
+		if err != nil {
+			return diag.Errorf("error updating BgpRouter. %s", err)
+		}
+	}
+
+	err = updateBgpRouterAddressFamilies(ctx, client, d, id)
+
+	if err != nil {
+		return diag.Errorf("error updating BgpRouter. %s", err)
+	}
 
 	return resourceBgpRouterRead(ctx, d, meta)
 }
 
 func resourceBgpRouterDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
-	id := d.Get("as").(int)
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	params := models.BgpRouter{}
-	params.Bgp.ID = id
+	id := d.Get("as").(int)
 
-	err := client.DeleteBgpRouter(params)
+	err = deleteBgp(ctx, client, models.BgpPath(id))
 
 	if err != nil {
 		return diag.Errorf("error deleting BgpRouter. %s", err)
@@ -113,16 +349,305 @@ func resourceBgpRouterDelete(ctx context.Context, d *schema.ResourceData, meta i
 	return nil
 }
 
-func resourceSetBgpRouter(d *schema.ResourceData, resp *models.BgpRouter) {
-	d.Set("as", resp.Bgp.ID)
-	d.Set("log_neighbor_changes", resp.Bgp.Bgp.LogNeighborChanges)
+func resourceSetBgpRouter(d *schema.ResourceData, entry map[string]interface{}) {
+	values := flattenBgpLeaves(d, entry, bgpRouterLeaves)
+	for attr, v := range values {
+		if !strings.Contains(attr, ".") {
+			d.Set(attr, v)
+		}
+	}
+	d.Set("bestpath", flattenBgpBlock(values, "bestpath"))
+
+	timers := []map[string]interface{}{}
+	if t := jsonObject(jsonObject(entry, "timers"), "bgp"); t != nil {
+		keepalive, _ := jsonMember(t, "keepalive-interval")
+		holdtime, _ := jsonMember(t, "holdtime")
+		minimum, _ := jsonMember(t, "minimum-neighbor-hold")
+		timers = append(timers, map[string]interface{}{
+			"keepalive_interval":    intValue(keepalive),
+			"holdtime":              intValue(holdtime),
+			"minimum_neighbor_hold": intValue(minimum),
+		})
+	}
+	d.Set("timers", timers)
+
+	ranges := []interface{}{}
+	list, _ := jsonMember(jsonObject(jsonObject(entry, "bgp"), "listen"), "range")
+	entries, _ := list.([]interface{})
+	for _, e := range entries {
+		m, _ := e.(map[string]interface{})
+		r, _ := jsonMember(m, "network-range")
+		peerGroup, _ := jsonMember(m, "peer-group")
+		ranges = append(ranges, map[string]interface{}{
+			"range":      stringValue(r),
+			"peer_group": stringValue(peerGroup),
+		})
+	}
+	d.Set("listen_range", ranges)
+
+	// address families are listed if configured or holding router settings
+	configured := map[string]bool{}
+	for _, v := range d.Get("address_family").(*schema.Set).List() {
+		configured[v.(map[string]interface{})["name"].(string)] = true
+	}
+	families := []interface{}{}
+	for _, af := range bgpAddressFamilyNames() {
+		content := bgpRouterAddressFamilyContent(entry, af)
+		found := false
+		for _, l := range bgpRouterAddressFamilyLeaves {
+			if _, ok := jsonPath(content, l.path); ok {
+				found = true
+			}
+		}
+		if !found && !configured[af] {
+			continue
+		}
+		values := map[string]interface{}{"name": af}
+		for _, l := range bgpRouterAddressFamilyLeaves {
+			v, ok := jsonPath(content, l.path)
+			if l.empty {
+				values[l.attr] = ok
+			} else {
+				values[l.attr] = intValue(v)
+			}
+		}
+		families = append(families, values)
+	}
+	d.Set("address_family", families)
+}
+
+// expandBgpRouter returns the content of the bgp entry of the leaves for
+// which changed is true, and the paths of the changed leaves to delete.
+func expandBgpRouter(d *schema.ResourceData, changed func(string) bool) (map[string]interface{}, []string) {
+	entry, leaves := expandBgpLeaves(bgpRouterLeaves, func(attr string) interface{} { return d.Get(attr) }, changed)
+
+	if changed("timers") {
+		if l := d.Get("timers").([]interface{}); len(l) > 0 && l[0] != nil {
+			m := l[0].(map[string]interface{})
+			timers := map[string]interface{}{
+				"keepalive-interval": m["keepalive_interval"].(int),
+				"holdtime":           m["holdtime"].(int),
+			}
+			if v := m["minimum_neighbor_hold"].(int); v > 0 {
+				timers["minimum-neighbor-hold"] = v
+			}
+			setJSONPath(entry, "timers/bgp", timers)
+		} else {
+			leaves = append(leaves, "timers/bgp")
+		}
+	}
+
+	if changed("listen_range") {
+		ranges := []interface{}{}
+		for _, v := range d.Get("listen_range").(*schema.Set).List() {
+			m := v.(map[string]interface{})
+			ranges = append(ranges, map[string]interface{}{
+				"network-range": m["range"].(string),
+				"peer-group":    m["peer_group"].(string),
+			})
+		}
+		if len(ranges) > 0 {
+			setJSONPath(entry, "bgp/listen/range", ranges)
+		}
+	}
+
+	return entry, leaves
+}
+
+// updateBgpRouterAddressFamilies writes the changed settings of the address
+// families. The address families themselves are left in place, they also hold
+// the config of neighbors and networks.
+func updateBgpRouterAddressFamilies(ctx context.Context, c *restconf.Client, d *schema.ResourceData, as int) error {
+	o, n := d.GetChange("address_family")
+	old := bgpRouterAddressFamilyMap(o.(*schema.Set))
+	new := bgpRouterAddressFamilyMap(n.(*schema.Set))
+
+	for _, af := range bgpAddressFamilyNames() {
+		ov, nv := old[af], new[af]
+		if ov == nil && nv == nil {
+			continue
+		}
+		get := func(attr string) interface{} {
+			if nv == nil {
+				return nil
+			}
+			return nv[attr]
+		}
+		changed := func(attr string) bool {
+			if ov == nil {
+				return !isZero(get(attr))
+			}
+			return fmt.Sprint(ov[attr]) != fmt.Sprint(get(attr))
+		}
+
+		content, leaves := expandBgpLeaves(bgpRouterAddressFamilyLeaves, get, changed)
+
+		if ov != nil {
+			if err := deleteBgpLeaves(ctx, c, bgpAddressFamilyPath(as, "", af), leaves); err != nil {
+				return err
+			}
+		}
+
+		if len(content) == 0 {
+			continue
+		}
+
+		body, err := bgpAddressFamilyBody(as, "", af, content)
+		if err != nil {
+			return err
+		}
+		if err := patchBgp(ctx, c, as, body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func bgpRouterAddressFamilyMap(s *schema.Set) map[string]map[string]interface{} {
+	r := map[string]map[string]interface{}{}
+	for _, v := range s.List() {
+		m := v.(map[string]interface{})
+		r[m["name"].(string)] = m
+	}
+	return r
+}
+
+// bgpRouterAddressFamilyContent returns the container of the address family af
+// of the global table in the bgp entry, nil if there is none.
+func bgpRouterAddressFamilyContent(entry map[string]interface{}, af string) map[string]interface{} {
+	f := bgpAddressFamilies[af]
+	list, _ := jsonPath(entry, fmt.Sprintf("address-family/no-vrf/%s", f.afi))
+	entries, _ := list.([]interface{})
+	for _, e := range entries {
+		m, _ := e.(map[string]interface{})
+		if name, _ := jsonMember(m, "af-name"); stringValue(name) == f.safi {
+			return jsonObject(m, af)
+		}
+	}
+
+	return nil
+}
+
+// removedListenRanges returns the listen ranges removed in this update.
+func removedListenRanges(d *schema.ResourceData) []string {
+	if !d.HasChange("listen_range") {
+		return nil
+	}
+	o, n := d.GetChange("listen_range")
+
+	keep := map[string]bool{}
+	for _, v := range n.(*schema.Set).List() {
+		keep[v.(map[string]interface{})["range"].(string)] = true
+	}
+
+	r := []string{}
+	for _, v := range o.(*schema.Set).List() {
+		if k := v.(map[string]interface{})["range"].(string); !keep[k] {
+			r = append(r, k)
+		}
+	}
+	sort.Strings(r)
+
+	return r
+}
+
+// expandBgpLeaves returns the content holding the leaves for which changed is
+// true, and the paths of the changed leaves that were unset. Boolean leaves
+// that aren't empty leaves are written either way.
+func expandBgpLeaves(leaves []bgpLeaf, get func(string) interface{}, changed func(string) bool) (map[string]interface{}, []string) {
+	content := map[string]interface{}{}
+	deleted := []string{}
+	for _, l := range leaves {
+		if !changed(l.attr) {
+			continue
+		}
+		v := get(l.attr)
+		b, isBool := v.(bool)
+		switch {
+		case isBool && !l.empty:
+			setJSONPath(content, l.path, b)
+		case isZero(v):
+			deleted = append(deleted, l.path)
+		case l.empty:
+			setJSONPath(content, l.path, []interface{}{nil})
+		default:
+			setJSONPath(content, l.path, v)
+		}
+	}
+
+	return content, deleted
+}
+
+// flattenBgpLeaves returns the values of the leaves in content by attribute,
+// typed after the attribute's current value.
+func flattenBgpLeaves(d *schema.ResourceData, content map[string]interface{}, leaves []bgpLeaf) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, l := range leaves {
+		v, ok := jsonPath(content, l.path)
+		switch d.Get(l.attr).(type) {
+		case bool:
+			if l.empty {
+				values[l.attr] = ok
+			} else {
+				b, _ := v.(bool)
+				values[l.attr] = b
+			}
+		case int:
+			values[l.attr] = intValue(v)
+		default:
+			values[l.attr] = stringValue(v)
+		}
+	}
+
+	return values
+}
+
+// flattenBgpBlock returns the single block name from the values of its
+// fields, keyed "<name>.0.<field>", no block if they are all unset.
+func flattenBgpBlock(values map[string]interface{}, name string) []map[string]interface{} {
+	block := map[string]interface{}{}
+	set := false
+	for attr, v := range values {
+		if field := strings.TrimPrefix(attr, name+".0."); field != attr {
+			block[field] = v
+			set = set || !isZero(v)
+		}
+	}
+	if !set {
+		return []map[string]interface{}{}
+	}
+
+	return []map[string]interface{}{block}
+}
+
+// jsonPath returns the value at the slash separated path below m.
+func jsonPath(m map[string]interface{}, path string) (interface{}, bool) {
+	var v interface{} = m
+	for _, name := range strings.Split(path, "/") {
+		o, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = jsonMember(o, name); !ok {
+			return nil, false
+		}
+	}
+
+	return v, true
 }
 
-func getCreateUpdateBgpRouterObject(d *schema.ResourceData, m *models.BgpRouter) *models.BgpRouter {
-	if v, ok := d.GetOk("log_neighbor_changes"); ok {
-		if b, ok := v.(bool); ok {
-			m.Bgp.Bgp.LogNeighborChanges = b
+// setJSONPath sets the value at the slash separated path below m, creating
+// the containers along the way.
+func setJSONPath(m map[string]interface{}, path string, v interface{}) {
+	names := strings.Split(path, "/")
+	for _, name := range names[:len(names)-1] {
+		c, ok := m[name].(map[string]interface{})
+		if !ok {
+			c = map[string]interface{}{}
+			m[name] = c
 		}
+		m = c
 	}
-	return m
+	m[names[len(names)-1]] = v
 }
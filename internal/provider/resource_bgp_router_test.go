@@ -1,9 +1,12 @@
 package provider
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf/restconftest"
 )
 
 func TestBgpRouter_basic(t *testing.T) {
@@ -15,6 +18,179 @@ func TestBgpRouter_basic(t *testing.T) {
 		// CheckDestroy: testAccCheckExampleResourceDestroy,
 		Steps: []resource.TestStep{
 			testAccCreateResourceFromExampleStep(rName),
+			testAccImportResourceFromExampleStep(rName),
 		},
 	})
 }
+
+func TestBgpRouter_mock(t *testing.T) {
+	rName := "iosxe_bgp_router"
+	srv := testAccMockDevice(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, "Cisco-IOS-XE-native:native/router/bgp=65420"),
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+			{
+				Config: testAccBgpRouterUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, "Cisco-IOS-XE-native:native/router/bgp=65420"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "log_neighbor_changes", "false"),
+				),
+			},
+			testAccImportResourceFromExampleStep(rName),
+		},
+	})
+}
+
+const testAccBgpRouterUpdateConfig = `
+resource "iosxe_bgp_router" "example" {
+  as                   = 65420
+  log_neighbor_changes = false
+}
+`
+
+func TestBgpRouter_mockSettings(t *testing.T) {
+	srv := testAccMockDevice(t)
+	bgp := "Cisco-IOS-XE-native:native/router/Cisco-IOS-XE-bgp:bgp=65420"
+	ipv4 := bgp + "/address-family/no-vrf/ipv4=unicast/ipv4-unicast"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, bgp),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccBgpRouterSettingsConfig(`
+  router_id                = "10.255.0.1"
+  default_local_preference = 200
+  graceful_restart         = true
+  listen_limit             = 50
+
+  bestpath {
+    as_path_multipath_relax = true
+    med_missing_as_worst    = true
+  }
+
+  timers {
+    keepalive_interval = 10
+    holdtime           = 30
+  }
+
+  listen_range {
+    range      = "10.0.0.0/24"
+    peer_group = "DYNAMIC"
+  }
+
+  address_family {
+    name                          = "ipv4-unicast"
+    maximum_paths                 = 4
+    maximum_paths_ibgp            = 2
+    default_information_originate = true
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, bgp+"/bgp/router-id/ip-id"),
+					testAccCheckMockExists(srv, bgp+"/bgp/bestpath/as-path/multipath-relax"),
+					testAccCheckMockExists(srv, bgp+"/timers/bgp/holdtime"),
+					testAccCheckMockExists(srv, bgp+"/bgp/listen/range=10.0.0.0%2F24"),
+					testAccCheckMockExists(srv, ipv4+"/maximum-paths/number-of-paths"),
+					testAccCheckMockExists(srv, ipv4+"/neighbor=7.7.7.7"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "router_id", "10.255.0.1"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "default_local_preference", "200"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "graceful_restart", "true"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "listen_limit", "50"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "bestpath.0.as_path_multipath_relax", "true"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "bestpath.0.med_confed", "false"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "timers.0.keepalive_interval", "10"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "listen_range.#", "1"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "address_family.#", "1"),
+					resource.TestCheckTypeSetElemNestedAttrs("iosxe_bgp_router.example", "address_family.*", map[string]string{
+						"name":                          "ipv4-unicast",
+						"maximum_paths":                 "4",
+						"maximum_paths_ibgp":            "2",
+						"default_information_originate": "true",
+					}),
+				),
+			},
+			{
+				ResourceName:      "iosxe_bgp_router.example",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				// the neighbor and its address family config survive the update
+				Config: testAccBgpRouterSettingsConfig(`
+  router_id = "10.255.0.2"
+
+  address_family {
+    name          = "ipv4-unicast"
+    maximum_paths = 8
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockDeleted(srv,
+						bgp+"/bgp/default/local-preference",
+						bgp+"/bgp/graceful-restart",
+						bgp+"/bgp/listen/limit",
+						bgp+"/bgp/bestpath/as-path/multipath-relax",
+						bgp+"/bgp/bestpath/med/missing-as-worst",
+						bgp+"/timers/bgp",
+						bgp+"/bgp/listen/range=10.0.0.0%2F24",
+						ipv4+"/maximum-paths/ibgp/number-of-paths",
+						ipv4+"/default-information/originate",
+					),
+					testAccCheckBgpRouterNotReplaced(srv, bgp),
+					testAccCheckMockExists(srv, bgp+"/neighbor=7.7.7.7"),
+					testAccCheckMockExists(srv, ipv4+"/neighbor=7.7.7.7/activate"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "router_id", "10.255.0.2"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "bestpath.#", "0"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "timers.#", "0"),
+					resource.TestCheckResourceAttr("iosxe_bgp_router.example", "listen_range.#", "0"),
+					resource.TestCheckTypeSetElemNestedAttrs("iosxe_bgp_router.example", "address_family.*", map[string]string{
+						"name":          "ipv4-unicast",
+						"maximum_paths": "8",
+					}),
+					resource.TestCheckNoResourceAttr("iosxe_bgp_router.example", "address_family.0.maximum_paths_ibgp"),
+					resource.TestCheckNoResourceAttr("iosxe_bgp_router.example", "address_family.0.default_information_originate"),
+				),
+			},
+		},
+	})
+}
+
+func testAccBgpRouterSettingsConfig(attrs string) string {
+	return `
+resource "iosxe_bgp_router" "example" {
+  as = 65420
+` + attrs + `
+}
+
+resource "iosxe_bgp_neighbor" "example" {
+  as        = iosxe_bgp_router.example.as
+  ip        = "7.7.7.7"
+  remote_as = 8900
+}
+`
+}
+
+// testAccCheckBgpRouterNotReplaced checks that router bgp was only written
+// once, when it was created.
+func testAccCheckBgpRouterNotReplaced(srv *restconftest.Server, path string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		puts := 0
+		for _, r := range srv.Requests() {
+			if r.Method == "PUT" && r.Path == restconftest.DataPath+path {
+				puts++
+			}
+		}
+		if puts != 1 {
+			return fmt.Errorf("expected a single PUT of %s, got %d", path, puts)
+		}
+		return nil
+	}
+}
+
+func TestBgpRouter_notFound(t *testing.T) {
+	testResourceReadNotFound(t, resourceBgpRouter(), "65420")
+}
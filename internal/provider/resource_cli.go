@@ -0,0 +1,378 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
+)
+
+const (
+	cliConfigRPC = "Cisco-IOS-XE-cli-rpc:config-ios-cli-rpc"
+	cliExecRPC   = "Cisco-IOS-XE-cli-rpc:exec-ios-cli-rpc"
+)
+
+func resourceCLI() *schema.Resource {
+	return &schema.Resource{
+		Description: "Manage config without YANG coverage as IOS CLI lines.",
+
+		CreateContext: resourceCLICreate,
+		ReadContext:   resourceCLIRead,
+		UpdateContext: resourceCLIUpdate,
+		DeleteContext: resourceCLIDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateDevice(resourceCLIImport),
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"device": deviceSchema(),
+			"commands": {
+				Description: "Config lines as in `show running-config`, sub-mode lines indented below their parent.",
+				Type:        schema.TypeList,
+				Required:    true,
+				MinItems:    1,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"delete_commands": {
+				Description: "Config lines applied on destroy. Nothing is removed from the device if unset.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceCLICreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = cliConfigure(ctx, client, expandStringList(d.Get("commands").([]interface{})))
+
+	if err != nil {
+		return diag.Errorf("error creating CLI config. %s", err)
+	}
+
+	d.SetId(resource.UniqueId())
+
+	return resourceCLIRead(ctx, d, meta)
+}
+
+func resourceCLIRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	commands := expandStringList(d.Get("commands").([]interface{}))
+	running, err := cliExec(ctx, client, cliShowCommand(commands))
+
+	if err != nil {
+		return diag.Errorf("error retrieving running-config. %s", err)
+	}
+
+	d.Set("commands", presentCLILines(commands, running))
+
+	return nil
+}
+
+func resourceCLIUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if d.HasChange("commands") {
+		o, n := d.GetChange("commands")
+		commands := expandStringList(n.([]interface{}))
+		lines := append(negatedCLILines(expandStringList(o.([]interface{})), commands), commands...)
+		err = cliConfigure(ctx, client, lines)
+
+		if err != nil {
+			return diag.Errorf("error updating CLI config. %s", err)
+		}
+	}
+
+	return resourceCLIRead(ctx, d, meta)
+}
+
+func resourceCLIDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	commands := expandStringList(d.Get("delete_commands").([]interface{}))
+	if len(commands) == 0 {
+		return nil
+	}
+
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = cliConfigure(ctx, client, commands)
+
+	if err != nil {
+		return diag.Errorf("error deleting CLI config. %s", err)
+	}
+
+	return nil
+}
+
+// resourceCLIImport takes the top level line of the config to import as ID,
+// and manages it with its sub-mode lines as found in the running config.
+func resourceCLIImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return nil, err
+	}
+
+	line := strings.TrimSpace(d.Id())
+	running, err := cliExec(ctx, client, cliShowCommand([]string{line}))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving running-config. %s", err)
+	}
+
+	commands := cliSection(running, line)
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("%q not found in the running config", line)
+	}
+
+	d.Set("commands", commands)
+	d.SetId(resource.UniqueId())
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func expandStringList(l []interface{}) []string {
+	r := make([]string, 0, len(l))
+	for _, v := range l {
+		s, _ := v.(string)
+		r = append(r, s)
+	}
+
+	return r
+}
+
+// cliConfigure applies config lines through the CLI RPC. IOS reports rejected
+// lines in the result text instead of failing the RPC.
+func cliConfigure(ctx context.Context, client *restconf.Client, lines []string) error {
+	result, err := cliRPC(ctx, client, cliConfigRPC, strings.Join(lines, "\n"))
+	if err != nil {
+		return err
+	}
+
+	for _, l := range strings.Split(result, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(l), "%") {
+			return fmt.Errorf("%s", strings.TrimSpace(result))
+		}
+	}
+
+	return nil
+}
+
+// cliExec runs an exec command and returns its output.
+func cliExec(ctx context.Context, client *restconf.Client, command string) (string, error) {
+	return cliRPC(ctx, client, cliExecRPC, command)
+}
+
+func cliRPC(ctx context.Context, client *restconf.Client, rpc string, clis string) (string, error) {
+	module := rpc[:strings.Index(rpc, ":")]
+
+	input, err := json.Marshal(map[string]interface{}{
+		module + ":input": map[string]interface{}{
+			"clis": clis,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Post(ctx, rpc, input)
+	if err != nil {
+		return "", err
+	}
+	if len(resp) == 0 {
+		return "", nil
+	}
+
+	output := map[string]struct {
+		Result string `json:"result"`
+	}{}
+	if err := json.Unmarshal(resp, &output); err != nil {
+		return "", fmt.Errorf("unable to decode %s output. %s", rpc, err)
+	}
+
+	return output[module+":output"].Result, nil
+}
+
+type cliLine struct {
+	raw      string
+	text     string
+	indent   int
+	children []*cliLine
+}
+
+// parseCLI builds the parent/child tree of config lines from their
+// indentation, skipping comments and the banner of show running-config.
+func parseCLI(lines []string) []*cliLine {
+	root := &cliLine{indent: -1}
+	stack := []*cliLine{root}
+
+	for _, l := range lines {
+		l = strings.TrimRight(l, " \t\r")
+		t := strings.TrimSpace(l)
+		if t == "" || t == "!" || t == "end" || strings.HasPrefix(t, "Building configuration") || strings.HasPrefix(t, "Current configuration") {
+			continue
+		}
+
+		n := &cliLine{
+			raw:    l,
+			text:   strings.Join(strings.Fields(t), " "),
+			indent: len(l) - len(strings.TrimLeft(l, " ")),
+		}
+		for len(stack) > 1 && stack[len(stack)-1].indent >= n.indent {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, n)
+		stack = append(stack, n)
+	}
+
+	return root.children
+}
+
+// cliShowCommand returns the command showing the part of the running config
+// holding commands, the sections starting with the words all their top level
+// lines start with. Regular expression characters in them match any
+// character, the lines are compared exactly by presentCLILines.
+func cliShowCommand(commands []string) string {
+	var prefix []string
+	for i, l := range parseCLI(commands) {
+		words := strings.Fields(strings.TrimPrefix(l.text, "no "))
+		if i == 0 {
+			prefix = words
+			continue
+		}
+		n := 0
+		for n < len(prefix) && n < len(words) && prefix[n] == words[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	if len(prefix) == 0 {
+		return "show running-config"
+	}
+
+	pattern := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`\^$.|?*+()[]{}`, r) {
+			return '.'
+		}
+		return r
+	}, strings.Join(prefix, " "))
+
+	return "show running-config | section ^" + pattern
+}
+
+// cliSection returns the top level line text of running with its sub-mode
+// lines.
+func cliSection(running string, text string) []string {
+	l := findCLILine(parseCLI(strings.Split(running, "\n")), strings.Join(strings.Fields(text), " "))
+	if l == nil {
+		return nil
+	}
+
+	r := []string{}
+	var walk func(l *cliLine)
+	walk = func(l *cliLine) {
+		r = append(r, l.raw)
+		for _, c := range l.children {
+			walk(c)
+		}
+	}
+	walk(l)
+
+	return r
+}
+
+// negatedCLILines returns the lines negating those of old missing from new,
+// below their parent for sub-mode lines. Lines below a negated line go with
+// it, "no" lines dropped from old are left as they are.
+func negatedCLILines(old []string, new []string) []string {
+	r := []string{}
+
+	var walk func(old []*cliLine, new []*cliLine, parents []string) bool
+	walk = func(old []*cliLine, new []*cliLine, parents []string) bool {
+		negated := false
+		for _, o := range old {
+			if strings.HasPrefix(o.text, "no ") {
+				continue
+			}
+			if n := findCLILine(new, o.text); n != nil {
+				if walk(o.children, n.children, append(append([]string{}, parents...), o.raw)) {
+					parents, negated = nil, true
+				}
+				continue
+			}
+			r = append(r, parents...)
+			r = append(r, o.raw[:o.indent]+"no "+o.text)
+			parents, negated = nil, true
+		}
+		return negated
+	}
+
+	walk(parseCLI(old), parseCLI(new), nil)
+
+	return r
+}
+
+func findCLILine(lines []*cliLine, text string) *cliLine {
+	for _, l := range lines {
+		if l.text == text {
+			return l
+		}
+	}
+	return nil
+}
+
+// presentCLILines returns the configured lines found in the running config,
+// in their configured form. Sub-mode lines are looked up below their parent,
+// "no" lines count as present when the line they negate is absent.
+func presentCLILines(commands []string, running string) []string {
+	r := []string{}
+
+	var walk func(want []*cliLine, have []*cliLine)
+	walk = func(want []*cliLine, have []*cliLine) {
+		for _, w := range want {
+			if strings.HasPrefix(w.text, "no ") {
+				if findCLILine(have, strings.TrimPrefix(w.text, "no ")) == nil {
+					r = append(r, w.raw)
+				}
+				continue
+			}
+			h := findCLILine(have, w.text)
+			if h == nil {
+				continue
+			}
+			r = append(r, w.raw)
+			walk(w.children, h.children)
+		}
+	}
+
+	walk(parseCLI(commands), parseCLI(strings.Split(running, "\n")))
+
+	return r
+}
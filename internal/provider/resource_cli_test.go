@@ -0,0 +1,366 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf/restconftest"
+)
+
+func TestCLI_basic(t *testing.T) {
+	rName := "iosxe_cli"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+		},
+	})
+}
+
+func TestCLI_mock(t *testing.T) {
+	rName := "iosxe_cli"
+	srv := testAccMockDevice(t)
+	dev := newTestCLIDevice(srv)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      dev.checkAbsent("ip access-list standard TF-TEST", "ip domain lookup source-interface Vlan666"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccExampleResourceConfig(rName),
+				Check: resource.ComposeTestCheckFunc(
+					dev.checkPresent("ip access-list standard TF-TEST", " 20 permit 10.0.0.2", "ip domain lookup source-interface Vlan666"),
+				),
+			},
+			{
+				PreConfig: func() {
+					dev.configure("ip access-list standard TF-TEST\n no 20 permit 10.0.0.2")
+				},
+				Config:             testAccExampleResourceConfig(rName),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccCLIUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					dev.checkPresent(" 20 permit 10.0.0.2", " 30 permit 10.0.0.3"),
+					dev.checkAbsent(" 10 permit 10.0.0.1"),
+				),
+			},
+			{
+				Config: testAccCLIRemoveConfig,
+				Check: resource.ComposeTestCheckFunc(
+					dev.checkPresent("ip access-list standard TF-TEST", " 20 permit 10.0.0.2"),
+					dev.checkAbsent(" 30 permit 10.0.0.3", "ip domain lookup source-interface Vlan666"),
+				),
+			},
+			{
+				ResourceName:  "iosxe_cli.example",
+				ImportState:   true,
+				ImportStateId: "ip access-list standard TF-TEST",
+				ImportStateCheck: func(s []*terraform.InstanceState) error {
+					want := map[string]string{
+						"commands.#": "2",
+						"commands.0": "ip access-list standard TF-TEST",
+						"commands.1": " 20 permit 10.0.0.2",
+					}
+					for k, v := range want {
+						if s[0].Attributes[k] != v {
+							return fmt.Errorf("expected %s %q, got %q", k, v, s[0].Attributes[k])
+						}
+					}
+					return nil
+				},
+			},
+			{
+				ResourceName:  "iosxe_cli.example",
+				ImportState:   true,
+				ImportStateId: "ip access-list standard MISSING",
+				ExpectError:   regexp.MustCompile(`not found in the running config`),
+			},
+		},
+	})
+}
+
+const testAccCLIRemoveConfig = `
+resource "iosxe_cli" "example" {
+  commands = [
+    "ip access-list standard TF-TEST",
+    " 20 permit 10.0.0.2",
+  ]
+  delete_commands = [
+    "no ip access-list standard TF-TEST",
+  ]
+}
+`
+
+const testAccCLIUpdateConfig = `
+resource "iosxe_cli" "example" {
+  commands = [
+    "ip access-list standard TF-TEST",
+    " no 10 permit 10.0.0.1",
+    " 20 permit 10.0.0.2",
+    " 30 permit 10.0.0.3",
+    "ip domain lookup source-interface Vlan666",
+  ]
+  delete_commands = [
+    "no ip access-list standard TF-TEST",
+    "no ip domain lookup source-interface Vlan666",
+  ]
+}
+`
+
+func TestPresentCLILines(t *testing.T) {
+	running := `Building configuration...
+
+Current configuration : 1234 bytes
+!
+hostname sw1
+!
+interface Vlan666
+ description  foo
+ ip address 10.0.0.1 255.255.255.0
+!
+ip access-list standard TF-TEST
+ 10 permit 10.0.0.1
+!
+end`
+
+	commands := []string{
+		"interface Vlan666",
+		" description foo",
+		" no shutdown",
+		" ip helper-address 10.1.1.1",
+		"interface Vlan667",
+		" description bar",
+		"no ip http server",
+		"hostname sw1",
+		"ip access-list standard TF-TEST",
+		" permit 10.0.0.1",
+	}
+	want := []string{
+		"interface Vlan666",
+		" description foo",
+		" no shutdown",
+		"no ip http server",
+		"hostname sw1",
+		"ip access-list standard TF-TEST",
+	}
+
+	if got := presentCLILines(commands, running); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestNegatedCLILines(t *testing.T) {
+	old := []string{
+		"interface Vlan666",
+		" description foo",
+		" no shutdown",
+		" ip helper-address 10.1.1.1",
+		"interface Vlan667",
+		" description bar",
+		"router bgp 65000",
+		" address-family ipv4",
+		"  network 10.0.0.0",
+		"  network 10.1.0.0",
+		"no ip http server",
+		"hostname sw1",
+	}
+	new := []string{
+		"interface Vlan666",
+		" description bar",
+		"router bgp 65000",
+		" address-family ipv4",
+		"  network 10.0.0.0",
+	}
+	want := []string{
+		"interface Vlan666",
+		" no description foo",
+		" no ip helper-address 10.1.1.1",
+		"no interface Vlan667",
+		"router bgp 65000",
+		" address-family ipv4",
+		"  no network 10.1.0.0",
+		"no hostname sw1",
+	}
+
+	if got := negatedCLILines(old, new); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got := negatedCLILines(new, new); len(got) != 0 {
+		t.Fatalf("expected nothing to negate, got %q", got)
+	}
+}
+
+func TestCLIShowCommand(t *testing.T) {
+	cases := []struct {
+		commands []string
+		want     string
+	}{
+		{[]string{"ip access-list standard TF-TEST", " 10 permit 10.0.0.1", "ip domain lookup"}, "show running-config | section ^ip"},
+		{[]string{"interface Vlan666", " description foo"}, "show running-config | section ^interface Vlan666"},
+		{[]string{"no ip http server", "ip http secure-server"}, "show running-config | section ^ip http"},
+		{[]string{"ip prefix-list A seq 5 permit 10.0.0.0/8 le 24", "ip prefix-list A seq 10 permit 0.0.0.0/0"}, "show running-config | section ^ip prefix-list A seq"},
+		{[]string{"username a|b privilege 15"}, "show running-config | section ^username a.b privilege 15"},
+		{[]string{"hostname sw1", "ip domain lookup"}, "show running-config"},
+	}
+
+	for _, c := range cases {
+		if got := cliShowCommand(c.commands); got != c.want {
+			t.Errorf("%q: expected %q, got %q", c.commands, c.want, got)
+		}
+	}
+}
+
+// testCLIDevice keeps a running config of top level lines with one level of
+// sub-mode lines and serves it through the CLI RPCs.
+type testCLIDevice struct {
+	mu     sync.Mutex
+	blocks []*testCLIBlock
+}
+
+type testCLIBlock struct {
+	line     string
+	children []string
+}
+
+func newTestCLIDevice(srv *restconftest.Server) *testCLIDevice {
+	dev := &testCLIDevice{}
+	srv.HandleRPC(cliConfigRPC, func(input map[string]interface{}) (map[string]interface{}, error) {
+		dev.configure(input["clis"].(string))
+		return map[string]interface{}{"result": ""}, nil
+	})
+	srv.HandleRPC(cliExecRPC, func(input map[string]interface{}) (map[string]interface{}, error) {
+		command := input["clis"].(string)
+		if command == "show running-config" {
+			return map[string]interface{}{"result": dev.running()}, nil
+		}
+		pattern := strings.TrimPrefix(command, "show running-config | section ")
+		if pattern == command {
+			return nil, fmt.Errorf("unexpected command %v", command)
+		}
+		return map[string]interface{}{"result": dev.section(regexp.MustCompile(pattern))}, nil
+	})
+	return dev
+}
+
+func (c *testCLIDevice) configure(clis string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var parent *testCLIBlock
+	for _, l := range strings.Split(clis, "\n") {
+		t := strings.TrimSpace(l)
+		if !strings.HasPrefix(l, " ") {
+			parent = nil
+			if strings.HasPrefix(t, "no ") {
+				for i, b := range c.blocks {
+					if b.line == strings.TrimPrefix(t, "no ") {
+						c.blocks = append(c.blocks[:i], c.blocks[i+1:]...)
+						break
+					}
+				}
+				continue
+			}
+			for _, b := range c.blocks {
+				if b.line == t {
+					parent = b
+				}
+			}
+			if parent == nil {
+				parent = &testCLIBlock{line: t}
+				c.blocks = append(c.blocks, parent)
+			}
+			continue
+		}
+		if parent == nil {
+			continue
+		}
+		if strings.HasPrefix(t, "no ") {
+			for i, child := range parent.children {
+				if child == strings.TrimPrefix(t, "no ") {
+					parent.children = append(parent.children[:i], parent.children[i+1:]...)
+					break
+				}
+			}
+			continue
+		}
+		found := false
+		for _, child := range parent.children {
+			found = found || child == t
+		}
+		if !found {
+			parent.children = append(parent.children, t)
+		}
+	}
+}
+
+func (c *testCLIDevice) running() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lines := []string{"Building configuration...", "", "!"}
+	for _, b := range c.blocks {
+		lines = append(lines, b.line)
+		for _, child := range b.children {
+			lines = append(lines, " "+child)
+		}
+		lines = append(lines, "!")
+	}
+	lines = append(lines, "end")
+
+	return strings.Join(lines, "\n")
+}
+
+// section returns the blocks whose top level line matches re, as the section
+// filter of show running-config.
+func (c *testCLIDevice) section(re *regexp.Regexp) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lines := []string{}
+	for _, b := range c.blocks {
+		if !re.MatchString(b.line) {
+			continue
+		}
+		lines = append(lines, b.line)
+		for _, child := range b.children {
+			lines = append(lines, " "+child)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (c *testCLIDevice) checkPresent(lines ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		running := c.running()
+		for _, l := range lines {
+			if !strings.Contains(running, "\n"+l+"\n") {
+				return fmt.Errorf("%q not found in running config:\n%s", l, running)
+			}
+		}
+		return nil
+	}
+}
+
+func (c *testCLIDevice) checkAbsent(lines ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		running := c.running()
+		for _, l := range lines {
+			if strings.Contains(running, "\n"+l+"\n") {
+				return fmt.Errorf("%q still in running config:\n%s", l, running)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceCommit() *schema.Resource {
+	return &schema.Resource{
+		Description: "Commit the writes staged in the candidate datastore of a device with `confirmed_commit`.",
+
+		CreateContext: resourceCommitCreate,
+		ReadContext:   schema.NoopContext,
+		DeleteContext: resourceCommitDelete,
+
+		CustomizeDiff: resourceCommitCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"device": deviceSchema(),
+			"triggers": {
+				Description: "Arbitrary values that commit again when changed.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"result": {
+				Description: "Outcome of the commit.",
+				Type:        schema.TypeString,
+				Computed:    true,
+				ForceNew:    true,
+			},
+		},
+	}
+}
+
+// resourceCommitCustomizeDiff commits again whenever a write to the device is
+// planned before it, which depends_on on the resources of the device ensures.
+func resourceCommitCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	c, ok := meta.(*apiClient)
+	if !ok || d.Id() == "" || !d.NewValueKnown("device") {
+		return nil
+	}
+	if c.confirmedCommit && c.writesPlanned(d.Get("device").(string)) {
+		return d.SetNewComputed("result")
+	}
+
+	return nil
+}
+
+func resourceCommitCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	c := meta.(*apiClient)
+	device := d.Get("device").(string)
+
+	if _, err := c.device(device); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if !c.confirmedCommit {
+		d.SetId(resource.UniqueId())
+		d.Set("result", "confirmed_commit is not set, writes are committed as they are made")
+		return nil
+	}
+
+	n, err := c.commitStaged(ctx, device)
+
+	if err != nil {
+		return diag.Errorf("error committing config. %s", err)
+	}
+
+	if n > 0 && c.saveConfigOnApply {
+		if err := c.saveConfig(ctx, d); err != nil {
+			return diag.Errorf("error saving config. %s", err)
+		}
+	}
+
+	d.SetId(resource.UniqueId())
+	d.Set("result", fmt.Sprintf("committed %d resources", n))
+
+	return nil
+}
+
+func resourceCommitDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Terraform destroys it before the resources in its depends_on, their
+	// deletes are committed by its replacement or CommitStaged
+	return nil
+}
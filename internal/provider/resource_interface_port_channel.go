@@ -2,13 +2,25 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/poroping/go-ios-xe-sdk/models"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
 )
 
+// port-channels take an optional primary IP on top of the shared interface leaves
+var portChannelLeaves = map[string]string{
+	"description": "description",
+	"ip":          "ip/address/primary",
+	"shutdown":    "shutdown",
+	"vrf":         "vrf",
+}
+
 func resourcePortChannel() *schema.Resource {
 	return &schema.Resource{
 		Description: "Manage an PortChannel interface.",
@@ -18,7 +30,19 @@ func resourcePortChannel() *schema.Resource {
 		UpdateContext: resourcePortChannelUpdate,
 		DeleteContext: resourcePortChannelDelete,
 
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateDevice(schema.ImportStatePassthroughContext),
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
+			"device": deviceSchema(),
 			"description": {
 				Description: "Interface description.",
 				Type:        schema.TypeString,
@@ -68,7 +92,11 @@ func resourcePortChannel() *schema.Resource {
 }
 
 func resourcePortChannelCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("name").(string)
 
 	params := models.PortChannel{}
@@ -76,7 +104,7 @@ func resourcePortChannelCreate(ctx context.Context, d *schema.ResourceData, meta
 
 	getCreateUpdatePortChannelObject(d, &params)
 
-	err := client.CreatePortChannel(params)
+	err = client.CreatePortChannel(params)
 
 	if err != nil {
 		return diag.Errorf("error creating PortChannel. %s", err)
@@ -88,33 +116,51 @@ func resourcePortChannelCreate(ctx context.Context, d *schema.ResourceData, meta
 }
 
 func resourcePortChannelRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
-	id := d.Get("name").(string)
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id := d.Id()
 
 	params := models.PortChannel{}
 	params.PortChannel.Name = id
 	resp, err := client.ReadPortChannel(params)
 
 	if err != nil {
+		if restconf.IsNotFound(err) {
+			log.Printf("[WARN] PortChannel %s not found, removing from state", id)
+			d.SetId("")
+			return nil
+		}
 		return diag.Errorf("error retrieving PortChannel. %s", err)
 	}
 
 	resourceSetPortChannel(d, &resp.PortChannel)
 
-	d.SetId(id)
-
 	return nil
 }
 
 func resourcePortChannelUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("name").(string)
 
 	params := models.PortChannel{}
 	params.PortChannel.Name = id
 	getCreateUpdatePortChannelObject(d, &params)
 
-	err := client.UpdatePortChannel(params)
+	leaves := append(clearedLeaves(d, portChannelLeaves), removedSecondaryIPLeaves(d)...)
+	err = deleteLeaves(client, fmt.Sprintf("%s=%s", models.PortChannelPath, id), leaves)
+
+	if err != nil {
+		return diag.Errorf("error updating PortChannel. %s", err)
+	}
+
+	err = client.UpdatePortChannel(params)
 
 	if err != nil {
 		return diag.Errorf("error updating PortChannel. %s", err)
@@ -126,12 +172,16 @@ func resourcePortChannelUpdate(ctx context.Context, d *schema.ResourceData, meta
 }
 
 func resourcePortChannelDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("name").(string)
 
 	params := models.PortChannel{}
 	params.PortChannel.Name = id
-	err := client.DeletePortChannel(params)
+	err = client.DeletePortChannel(params)
 
 	if err != nil {
 		return diag.Errorf("error deleting PortChannel. %s", err)
@@ -145,11 +195,15 @@ func resourcePortChannelDelete(ctx context.Context, d *schema.ResourceData, meta
 func resourceSetPortChannel(d *schema.ResourceData, resp *models.Interface) {
 	if resp.Description != nil {
 		d.Set("description", resp.Description)
+	} else {
+		d.Set("description", "")
 	}
-	if resp.IP != nil {
+	if resp.IP != nil && resp.IP.Address != nil {
 		if resp.IP.Address.Primary != nil {
 			resp.IP.Address.Primary.SetCIDR()
 			d.Set("ip", resp.IP.Address.Primary.CIDR)
+		} else {
+			d.Set("ip", "")
 		}
 		d.Set("secondary_ip", flattenPortChannelSecondaryIPs(resp.IP.Address.Secondary))
 	}
@@ -159,6 +213,11 @@ func resourceSetPortChannel(d *schema.ResourceData, resp *models.Interface) {
 	} else {
 		d.Set("shutdown", false)
 	}
+	if resp.Vrf != nil {
+		d.Set("vrf", resp.Vrf.Forwarding)
+	} else {
+		d.Set("vrf", "")
+	}
 }
 
 func flattenPortChannelSecondaryIPs(input *[]models.SecondaryIPAddress) []map[string]interface{} {
@@ -2,11 +2,15 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/poroping/go-ios-xe-sdk/models"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
 )
 
 func resourcePortChannelSubinterface() *schema.Resource {
@@ -18,7 +22,19 @@ func resourcePortChannelSubinterface() *schema.Resource {
 		UpdateContext: resourcePortChannelSubinterfaceUpdate,
 		DeleteContext: resourcePortChannelSubinterfaceDelete,
 
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateDevice(schema.ImportStatePassthroughContext),
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
+			"device": deviceSchema(),
 			"description": {
 				Description: "Interface description.",
 				Type:        schema.TypeString,
@@ -75,7 +91,11 @@ func resourcePortChannelSubinterface() *schema.Resource {
 }
 
 func resourcePortChannelSubinterfaceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("name").(string)
 
 	params := models.PortChannelSubinterface{}
@@ -83,7 +103,7 @@ func resourcePortChannelSubinterfaceCreate(ctx context.Context, d *schema.Resour
 
 	getCreateUpdatePortChannelSubinterfaceObject(d, &params)
 
-	err := client.CreatePortChannelSubinterface(params)
+	err = client.CreatePortChannelSubinterface(params)
 
 	if err != nil {
 		return diag.Errorf("error creating PortChannelSubinterface. %s", err)
@@ -95,33 +115,51 @@ func resourcePortChannelSubinterfaceCreate(ctx context.Context, d *schema.Resour
 }
 
 func resourcePortChannelSubinterfaceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
-	id := d.Get("name").(string)
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id := d.Id()
 
 	params := models.PortChannelSubinterface{}
 	params.PortChannelSubinterface.Name = id
 	resp, err := client.ReadPortChannelSubinterface(params)
 
 	if err != nil {
+		if restconf.IsNotFound(err) {
+			log.Printf("[WARN] PortChannelSubinterface %s not found, removing from state", id)
+			d.SetId("")
+			return nil
+		}
 		return diag.Errorf("error retrieving PortChannelSubinterface. %s", err)
 	}
 
 	resourceSetPortChannelSubinterface(d, &resp.PortChannelSubinterface)
 
-	d.SetId(id)
-
 	return nil
 }
 
 func resourcePortChannelSubinterfaceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("name").(string)
 
 	params := models.PortChannelSubinterface{}
 	params.PortChannelSubinterface.Name = id
 	getCreateUpdatePortChannelSubinterfaceObject(d, &params)
 
-	err := client.UpdatePortChannelSubinterface(params)
+	leaves := append(clearedLeaves(d, interfaceLeaves), removedSecondaryIPLeaves(d)...)
+	err = deleteLeaves(client, fmt.Sprintf("%s=%s", models.PortChannelSubinterfacePath, id), leaves)
+
+	if err != nil {
+		return diag.Errorf("error updating PortChannelSubinterface. %s", err)
+	}
+
+	err = client.UpdatePortChannelSubinterface(params)
 
 	if err != nil {
 		return diag.Errorf("error updating PortChannelSubinterface. %s", err)
@@ -133,12 +171,16 @@ func resourcePortChannelSubinterfaceUpdate(ctx context.Context, d *schema.Resour
 }
 
 func resourcePortChannelSubinterfaceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("name").(string)
 
 	params := models.PortChannelSubinterface{}
 	params.PortChannelSubinterface.Name = id
-	err := client.DeletePortChannelSubinterface(params)
+	err = client.DeletePortChannelSubinterface(params)
 
 	if err != nil {
 		return diag.Errorf("error deleting PortChannelSubinterface. %s", err)
@@ -152,19 +194,35 @@ func resourcePortChannelSubinterfaceDelete(ctx context.Context, d *schema.Resour
 func resourceSetPortChannelSubinterface(d *schema.ResourceData, resp *models.Interface) {
 	if resp.Description != nil {
 		d.Set("description", resp.Description)
+	} else {
+		d.Set("description", "")
+	}
+	if resp.IP != nil && resp.IP.Address != nil && resp.IP.Address.Primary != nil {
+		resp.IP.Address.Primary.SetCIDR()
+		d.Set("ip", resp.IP.Address.Primary.CIDR)
+	} else {
+		d.Set("ip", "")
 	}
-	resp.IP.Address.Primary.SetCIDR()
-	d.Set("ip", resp.IP.Address.Primary.CIDR)
 	d.Set("name", resp.Name)
-	d.Set("secondary_ip", flattenPortChannelSubinterfaceSecondaryIPs(resp.IP.Address.Secondary))
+	if resp.IP != nil && resp.IP.Address != nil {
+		d.Set("secondary_ip", flattenPortChannelSubinterfaceSecondaryIPs(resp.IP.Address.Secondary))
+	} else {
+		d.Set("secondary_ip", nil)
+	}
 	if resp.Shutdown != nil {
 		d.Set("shutdown", true)
 	} else {
 		d.Set("shutdown", false)
 	}
-	d.Set("vlanid", resp.Encapsulation.Dot1Q.VlanID)
+	if resp.Encapsulation != nil && resp.Encapsulation.Dot1Q != nil && resp.Encapsulation.Dot1Q.VlanID != nil {
+		d.Set("vlanid", resp.Encapsulation.Dot1Q.VlanID)
+	} else {
+		d.Set("vlanid", 0)
+	}
 	if resp.Vrf != nil {
 		d.Set("vrf", resp.Vrf.Forwarding)
+	} else {
+		d.Set("vrf", "")
 	}
 }
 
@@ -1,9 +1,11 @@
 package provider
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func TestPortChannelSubinterface_basic(t *testing.T) {
@@ -15,6 +17,102 @@ func TestPortChannelSubinterface_basic(t *testing.T) {
 		// CheckDestroy: testAccCheckExampleResourceDestroy,
 		Steps: []resource.TestStep{
 			testAccCreateResourceFromExampleStep(rName),
+			testAccImportResourceFromExampleStep(rName),
 		},
 	})
 }
+
+func TestPortChannelSubinterface_mock(t *testing.T) {
+	rName := "iosxe_interface_port_channel_subinterface"
+	srv := testAccMockDevice(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, "Cisco-IOS-XE-native:native/interface/Port-channel-subinterface/Port-channel=69.421", "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR"),
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+			{
+				Config: testAccPortChannelSubinterfaceUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, "Cisco-IOS-XE-native:native/interface/Port-channel-subinterface/Port-channel=69.421"),
+					resource.TestCheckResourceAttr("iosxe_interface_port_channel_subinterface.example", "description", "UPDATED"),
+					resource.TestCheckResourceAttr("iosxe_interface_port_channel_subinterface.example", "secondary_ip.#", "2"),
+				),
+			},
+			testAccImportResourceFromExampleStep(rName),
+		},
+	})
+}
+
+const testAccPortChannelSubinterfaceUpdateConfig = `
+resource "iosxe_vrf" "example" {
+  name        = "FOOBAR"
+  description = "ACC-TEST"
+  rd          = "566:4560"
+
+  address_family {
+    ip_version = 4
+  }
+}
+
+resource "iosxe_interface_port_channel_subinterface" "example" {
+  name        = "69.421"
+  vlanid      = 421
+  description = "UPDATED"
+  ip          = "192.1.1.1/29"
+  shutdown    = false
+  vrf         = iosxe_vrf.example.name
+
+  secondary_ip {
+    ip = "10.55.6.1/30"
+  }
+
+  secondary_ip {
+    ip = "10.55.7.1/30"
+  }
+}
+`
+
+func TestPortChannelSubinterface_mockImportWithoutAddress(t *testing.T) {
+	srv := testAccMockDevice(t)
+	srv.Put("Cisco-IOS-XE-native:native/interface/Port-channel-subinterface/Port-channel=69.422", `{"Cisco-IOS-XE-native:Port-channel": {"name": "69.422", "description": "UNNUMBERED"}}`)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:        testAccPortChannelSubinterfaceImportConfig,
+				ResourceName:  "iosxe_interface_port_channel_subinterface.example",
+				ImportState:   true,
+				ImportStateId: "69.422",
+				ImportStateCheck: func(s []*terraform.InstanceState) error {
+					if len(s) != 1 {
+						return fmt.Errorf("expected 1 state, got %d", len(s))
+					}
+					for attr, want := range map[string]string{
+						"description":    "UNNUMBERED",
+						"ip":             "",
+						"secondary_ip.#": "0",
+						"vlanid":         "0",
+					} {
+						if got := s[0].Attributes[attr]; got != want {
+							return fmt.Errorf("expected %s to be %q, got %q", attr, want, got)
+						}
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+const testAccPortChannelSubinterfaceImportConfig = `
+resource "iosxe_interface_port_channel_subinterface" "example" {
+  name   = "69.422"
+  vlanid = 422
+}
+`
+
+func TestPortChannelSubinterface_notFound(t *testing.T) {
+	testResourceReadNotFound(t, resourcePortChannelSubinterface(), "69.421")
+}
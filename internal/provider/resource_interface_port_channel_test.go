@@ -15,6 +15,82 @@ func TestPortChannel_basic(t *testing.T) {
 		// CheckDestroy: testAccCheckExampleResourceDestroy,
 		Steps: []resource.TestStep{
 			testAccCreateResourceFromExampleStep(rName),
+			testAccImportResourceFromExampleStep(rName),
 		},
 	})
 }
+
+func TestPortChannel_mock(t *testing.T) {
+	rName := "iosxe_interface_port_channel"
+	srv := testAccMockDevice(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, "Cisco-IOS-XE-native:native/interface/Port-channel=56"),
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+			{
+				Config: testAccPortChannelUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, "Cisco-IOS-XE-native:native/interface/Port-channel=56"),
+					resource.TestCheckResourceAttr("iosxe_interface_port_channel.example", "description", "UPDATED"),
+				),
+			},
+			testAccImportResourceFromExampleStep(rName),
+		},
+	})
+}
+
+const testAccPortChannelUpdateConfig = `
+resource "iosxe_interface_port_channel" "example" {
+  name        = "56"
+  description = "UPDATED"
+}
+`
+
+func TestPortChannel_mockClearAttributes(t *testing.T) {
+	srv := testAccMockDevice(t)
+	path := "Cisco-IOS-XE-native:native/interface/Port-channel=56"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "iosxe_interface_port_channel" "example" {
+  name        = "56"
+  description = "totallyterraformed"
+  ip          = "192.168.56.1/24"
+  shutdown    = true
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("iosxe_interface_port_channel.example", "ip", "192.168.56.1/24"),
+					resource.TestCheckResourceAttr("iosxe_interface_port_channel.example", "shutdown", "true"),
+				),
+			},
+			{
+				Config: `
+resource "iosxe_interface_port_channel" "example" {
+  name     = "56"
+  shutdown = false
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockDeleted(srv,
+						path+"/description",
+						path+"/ip/address/primary",
+						path+"/shutdown",
+					),
+					resource.TestCheckResourceAttr("iosxe_interface_port_channel.example", "description", ""),
+					resource.TestCheckResourceAttr("iosxe_interface_port_channel.example", "ip", ""),
+					resource.TestCheckResourceAttr("iosxe_interface_port_channel.example", "shutdown", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestPortChannel_notFound(t *testing.T) {
+	testResourceReadNotFound(t, resourcePortChannel(), "56")
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/poroping/go-ios-xe-sdk/models"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
 )
 
 func resourceVlan() *schema.Resource {
@@ -21,7 +23,19 @@ func resourceVlan() *schema.Resource {
 		UpdateContext: resourceVlanUpdate,
 		DeleteContext: resourceVlanDelete,
 
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateDevice(schema.ImportStatePassthroughContext),
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
+			"device": deviceSchema(),
 			"description": {
 				Description: "Interface description.",
 				Type:        schema.TypeString,
@@ -77,7 +91,11 @@ func resourceVlan() *schema.Resource {
 }
 
 func resourceVlanCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("vlanid").(int)
 
 	params := models.Vlan{}
@@ -85,7 +103,7 @@ func resourceVlanCreate(ctx context.Context, d *schema.ResourceData, meta interf
 
 	getCreateUpdateVlanObject(d, &params)
 
-	err := client.CreateVlan(params)
+	err = client.CreateVlan(params)
 
 	if err != nil {
 		return diag.Errorf("error creating Vlan. %s", err)
@@ -97,33 +115,55 @@ func resourceVlanCreate(ctx context.Context, d *schema.ResourceData, meta interf
 }
 
 func resourceVlanRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
-	id := d.Get("vlanid").(int)
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id, err := strconv.Atoi(d.Id())
+
+	if err != nil {
+		return diag.Errorf("error parsing Vlan ID %q. %s", d.Id(), err)
+	}
 
 	params := models.Vlan{}
 	params.Vlan.Name = strconv.Itoa(id)
 	resp, err := client.ReadVlan(params)
 
 	if err != nil {
+		if restconf.IsNotFound(err) {
+			log.Printf("[WARN] Vlan %d not found, removing from state", id)
+			d.SetId("")
+			return nil
+		}
 		return diag.Errorf("error retrieving Vlan. %s", err)
 	}
 
 	resourceSetVlan(d, &resp.Vlan)
 
-	d.SetId(strconv.Itoa(id))
-
 	return nil
 }
 
 func resourceVlanUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("vlanid").(int)
 
 	params := models.Vlan{}
 	params.Vlan.Name = strconv.Itoa(id)
 	getCreateUpdateVlanObject(d, &params)
 
-	err := client.UpdateVlan(params)
+	leaves := append(clearedLeaves(d, interfaceLeaves), removedSecondaryIPLeaves(d)...)
+	err = deleteLeaves(client, fmt.Sprintf("%s=%d", models.VlanPath, id), leaves)
+
+	if err != nil {
+		return diag.Errorf("error updating Vlan. %s", err)
+	}
+
+	err = client.UpdateVlan(params)
 
 	if err != nil {
 		return diag.Errorf("error updating Vlan. %s", err)
@@ -135,12 +175,16 @@ func resourceVlanUpdate(ctx context.Context, d *schema.ResourceData, meta interf
 }
 
 func resourceVlanDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("vlanid").(int)
 
 	params := models.Vlan{}
 	params.Vlan.Name = strconv.Itoa(id)
-	err := client.DeleteVlan(params)
+	err = client.DeleteVlan(params)
 
 	if err != nil {
 		return diag.Errorf("error deleting Vlan. %s", err)
@@ -154,11 +198,18 @@ func resourceVlanDelete(ctx context.Context, d *schema.ResourceData, meta interf
 func resourceSetVlan(d *schema.ResourceData, resp *models.Interface) {
 	if resp.Description != nil {
 		d.Set("description", resp.Description)
+	} else {
+		d.Set("description", "")
+	}
+	if resp.IP != nil && resp.IP.Address != nil && resp.IP.Address.Primary != nil {
+		resp.IP.Address.Primary.SetCIDR()
+		d.Set("ip", resp.IP.Address.Primary.CIDR)
+		d.Set("secondary_ip", flattenVlanSecondaryIPs(resp.IP.Address.Secondary))
+	} else {
+		d.Set("ip", "")
+		d.Set("secondary_ip", nil)
 	}
-	resp.IP.Address.Primary.SetCIDR()
-	d.Set("ip", resp.IP.Address.Primary.CIDR)
 	d.Set("name", fmt.Sprintf("%s%v", models.VlanName, resp.Name))
-	d.Set("secondary_ip", flattenVlanSecondaryIPs(resp.IP.Address.Secondary))
 	if resp.Shutdown != nil {
 		d.Set("shutdown", true)
 	} else {
@@ -171,6 +222,8 @@ func resourceSetVlan(d *schema.ResourceData, resp *models.Interface) {
 	d.Set("vlanid", id)
 	if resp.Vrf != nil {
 		d.Set("vrf", resp.Vrf.Forwarding)
+	} else {
+		d.Set("vrf", "")
 	}
 }
 
@@ -15,6 +15,121 @@ func TestVlan_basic(t *testing.T) {
 		// CheckDestroy: testAccCheckExampleResourceDestroy,
 		Steps: []resource.TestStep{
 			testAccCreateResourceFromExampleStep(rName),
+			testAccImportResourceFromExampleStep(rName),
 		},
 	})
 }
+
+func TestVlan_mock(t *testing.T) {
+	rName := "iosxe_interface_vlan"
+	srv := testAccMockDevice(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, "Cisco-IOS-XE-native:native/interface/Vlan=666", "Cisco-IOS-XE-native:native/vlan/vlan-list=666", "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR"),
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+			{
+				Config: testAccVlanUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, "Cisco-IOS-XE-native:native/interface/Vlan=666"),
+					resource.TestCheckResourceAttr("iosxe_interface_vlan.example", "description", "UPDATED"),
+					resource.TestCheckResourceAttr("iosxe_interface_vlan.example", "ip", "192.168.67.6/24"),
+					resource.TestCheckResourceAttr("iosxe_interface_vlan.example", "secondary_ip.#", "0"),
+				),
+			},
+			testAccImportResourceFromExampleStep(rName),
+		},
+	})
+}
+
+const testAccVlanUpdateConfig = `
+resource "iosxe_vrf" "example" {
+  name        = "FOOBAR"
+  description = "ACC-TEST"
+  rd          = "566:4560"
+
+  address_family {
+    ip_version = 4
+  }
+}
+
+resource "iosxe_l2_vlan" "example" {
+  vlanid = 666
+  name   = "IoT"
+}
+
+resource "iosxe_interface_vlan" "example" {
+  vlanid      = iosxe_l2_vlan.example.vlanid
+  description = "UPDATED"
+  ip          = "192.168.67.6/24"
+  shutdown    = false
+  vrf         = iosxe_vrf.example.name
+}
+`
+
+func TestVlan_mockClearAttributes(t *testing.T) {
+	srv := testAccMockDevice(t)
+	path := "Cisco-IOS-XE-native:native/interface/Vlan=666"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVlanClearConfig(`
+  description = "totallyterraformed"
+  shutdown    = true
+  vrf         = iosxe_vrf.example.name
+
+  secondary_ip {
+    ip = "10.55.2.1/30"
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("iosxe_interface_vlan.example", "shutdown", "true"),
+					resource.TestCheckResourceAttr("iosxe_interface_vlan.example", "vrf", "FOOBAR"),
+				),
+			},
+			{
+				Config: testAccVlanClearConfig(`
+  shutdown = false
+`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockDeleted(srv,
+						path+"/description",
+						path+"/shutdown",
+						path+"/vrf",
+						path+"/ip/address/secondary=10.55.2.1",
+					),
+					resource.TestCheckResourceAttr("iosxe_interface_vlan.example", "description", ""),
+					resource.TestCheckResourceAttr("iosxe_interface_vlan.example", "shutdown", "false"),
+					resource.TestCheckResourceAttr("iosxe_interface_vlan.example", "vrf", ""),
+					resource.TestCheckResourceAttr("iosxe_interface_vlan.example", "secondary_ip.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func testAccVlanClearConfig(attrs string) string {
+	return `
+resource "iosxe_vrf" "example" {
+  name = "FOOBAR"
+  rd   = "566:4560"
+
+  address_family {
+    ip_version = 4
+  }
+}
+
+resource "iosxe_interface_vlan" "example" {
+  vlanid = 666
+  ip     = "192.168.66.6/24"
+` + attrs + `
+}
+`
+}
+
+func TestVlan_notFound(t *testing.T) {
+	testResourceReadNotFound(t, resourceVlan(), "666")
+}
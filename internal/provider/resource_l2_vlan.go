@@ -2,12 +2,15 @@ package provider
 
 import (
 	"context"
+	"log"
 	"strconv"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/poroping/go-ios-xe-sdk/models"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
 )
 
 func resourceL2Vlan() *schema.Resource {
@@ -19,7 +22,19 @@ func resourceL2Vlan() *schema.Resource {
 		UpdateContext: resourceL2VlanUpdate,
 		DeleteContext: resourceL2VlanDelete,
 
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateDevice(schema.ImportStatePassthroughContext),
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
+			"device": deviceSchema(),
 			"name": {
 				Description: "VLAN name.",
 				Type:        schema.TypeString,
@@ -38,7 +53,11 @@ func resourceL2Vlan() *schema.Resource {
 }
 
 func resourceL2VlanCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("vlanid").(int)
 
 	params := models.L2VlanList{}
@@ -46,7 +65,7 @@ func resourceL2VlanCreate(ctx context.Context, d *schema.ResourceData, meta inte
 
 	getCreateUpdateL2VlanObject(d, &params.VlanList)
 
-	err := client.CreateL2Vlan(params)
+	err = client.CreateL2Vlan(params)
 
 	if err != nil {
 		return diag.Errorf("error creating Vlan. %s", err)
@@ -58,8 +77,16 @@ func resourceL2VlanCreate(ctx context.Context, d *schema.ResourceData, meta inte
 }
 
 func resourceL2VlanRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
-	id := d.Get("vlanid").(int)
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id, err := strconv.Atoi(d.Id())
+
+	if err != nil {
+		return diag.Errorf("error parsing Vlan ID %q. %s", d.Id(), err)
+	}
 
 	params := models.L2VlanList{}
 	params.VlanList.ID = id
@@ -67,18 +94,27 @@ func resourceL2VlanRead(ctx context.Context, d *schema.ResourceData, meta interf
 	resp, err := client.ReadL2Vlan(params)
 
 	if err != nil {
+		if restconf.IsNotFound(err) {
+			log.Printf("[WARN] Vlan %d not found, removing from state", id)
+			d.SetId("")
+			return nil
+		}
 		return diag.Errorf("error retrieving Vlan. %s", err)
 	}
 
 	resourceSetL2Vlan(d, &resp.VlanList)
 
-	d.SetId(strconv.Itoa(id))
+	d.Set("vlanid", id)
 
 	return nil
 }
 
 func resourceL2VlanUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("vlanid").(int)
 
 	params := models.L2VlanList{}
@@ -86,7 +122,7 @@ func resourceL2VlanUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 
 	getCreateUpdateL2VlanObject(d, &params.VlanList)
 
-	err := client.UpdateL2Vlan(params)
+	err = client.UpdateL2Vlan(params)
 
 	if err != nil {
 		return diag.Errorf("error updating Vlan. %s", err)
@@ -98,13 +134,17 @@ func resourceL2VlanUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 }
 
 func resourceL2VlanDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("vlanid").(int)
 
 	params := models.L2VlanList{}
 	params.VlanList.ID = id
 
-	err := client.DeleteL2Vlan(params)
+	err = client.DeleteL2Vlan(params)
 
 	if err != nil {
 		return diag.Errorf("error deleting Vlan. %s", err)
@@ -15,6 +15,39 @@ func TestL2Vlan_basic(t *testing.T) {
 		// CheckDestroy: testAccCheckExampleResourceDestroy,
 		Steps: []resource.TestStep{
 			testAccCreateResourceFromExampleStep(rName),
+			testAccImportResourceFromExampleStep(rName),
 		},
 	})
 }
+
+func TestL2Vlan_mock(t *testing.T) {
+	rName := "iosxe_l2_vlan"
+	srv := testAccMockDevice(t)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, "Cisco-IOS-XE-native:native/vlan/vlan-list=420"),
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+			{
+				Config: testAccL2VlanUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, "Cisco-IOS-XE-native:native/vlan/vlan-list=420"),
+					resource.TestCheckResourceAttr("iosxe_l2_vlan.example", "name", "Cameras"),
+				),
+			},
+			testAccImportResourceFromExampleStep(rName),
+		},
+	})
+}
+
+const testAccL2VlanUpdateConfig = `
+resource "iosxe_l2_vlan" "example" {
+  vlanid = 420
+  name   = "Cameras"
+}
+`
+
+func TestL2Vlan_notFound(t *testing.T) {
+	testResourceReadNotFound(t, resourceL2Vlan(), "420")
+}
@@ -0,0 +1,257 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
+)
+
+func resourceRestconf() *schema.Resource {
+	return &schema.Resource{
+		Description: "Manage the config at an arbitrary RESTCONF path, for models without a dedicated resource.",
+
+		CreateContext: resourceRestconfCreate,
+		ReadContext:   resourceRestconfRead,
+		UpdateContext: resourceRestconfUpdate,
+		DeleteContext: resourceRestconfDelete,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateDevice(resourceRestconfImport),
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"device": deviceSchema(),
+			"path": {
+				Description:  "Path below `/restconf/data`, e.g. `Cisco-IOS-XE-native:native/ntp`.",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotWhiteSpace,
+			},
+			"attributes": {
+				Description:      "JSON encoded content of the node at `path`, without the top level member.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateFunc:     validation.StringIsJSON,
+				DiffSuppressFunc: suppressEquivalentJSON,
+			},
+			"method": {
+				Description:  "`PUT` replaces the node with `attributes`, `PATCH` merges `attributes` into it and deletes removed members.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "PUT",
+				ValidateFunc: validation.StringInSlice([]string{"PUT", "PATCH"}, false),
+			},
+			"lists": {
+				Description: "Lists in `attributes` managed as a whole, mapping the path of the list below the node to its comma separated key leaves, e.g. `{\"server/server-list\" = \"ip-address\"}`. Entries added outside of Terraform show as drift and are removed.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceRestconfCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	path := d.Get("path").(string)
+
+	attributes, err := decodeJSON([]byte(d.Get("attributes").(string)))
+	if err != nil {
+		return diag.Errorf("error decoding attributes. %s", err)
+	}
+
+	switch d.Get("method").(string) {
+	case "PATCH":
+		// a PATCH target has to exist, so merge the node into its parent
+		var value interface{} = attributes
+		if isListEntryPath(path) {
+			value = []interface{}{attributes}
+		}
+		parent := parentPath(path)
+		var payload []byte
+		if parent == "" {
+			payload, err = restconfPayload(path, value)
+		} else {
+			payload, err = restconfPayload(parent, map[string]interface{}{restconfChildName(path): value})
+		}
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		err = client.Patch(ctx, parent, payload)
+		if err != nil {
+			return diag.Errorf("error creating %s. %s", path, err)
+		}
+	default:
+		payload, err := restconfPayload(path, attributes)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		err = client.Put(ctx, path, payload)
+		if err != nil {
+			return diag.Errorf("error creating %s. %s", path, err)
+		}
+	}
+
+	d.SetId(path)
+
+	return resourceRestconfRead(ctx, d, meta)
+}
+
+func resourceRestconfRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	path := d.Id()
+
+	resp, err := client.Get(ctx, path, nil)
+
+	if err != nil {
+		if restconf.IsNotFound(err) {
+			log.Printf("[WARN] %s not found, removing from state", path)
+			d.SetId("")
+			return nil
+		}
+		return diag.Errorf("error retrieving %s. %s", path, err)
+	}
+
+	value, err := restconfValue(resp)
+	if err != nil {
+		return diag.Errorf("error decoding %s. %s", path, err)
+	}
+
+	// some releases return a list entry as a list of one
+	if l, isList := value.([]interface{}); isList && len(l) == 1 && isListEntryPath(path) {
+		value = l[0]
+	}
+
+	// imported resources have no attributes yet and take everything on the device
+	if v := d.Get("attributes").(string); v != "" {
+		attributes, err := decodeJSON([]byte(v))
+		if err != nil {
+			return diag.Errorf("error decoding attributes. %s", err)
+		}
+		value = restrictJSON(value, attributes, normalizeListPaths(d.Get("lists").(map[string]interface{})), "")
+	}
+
+	attributes, err := encodeJSON(value)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("path", path)
+	d.Set("attributes", attributes)
+
+	return nil
+}
+
+func resourceRestconfUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	path := d.Id()
+
+	attributes, err := decodeJSON([]byte(d.Get("attributes").(string)))
+	if err != nil {
+		return diag.Errorf("error decoding attributes. %s", err)
+	}
+
+	payload, err := restconfPayload(path, attributes)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	switch d.Get("method").(string) {
+	case "PATCH":
+		// a merge keeps members missing from the payload, delete them first
+		if d.HasChange("attributes") {
+			o, _ := d.GetChange("attributes")
+			old, err := decodeJSON([]byte(o.(string)))
+			if err == nil {
+				lists := normalizeListPaths(d.Get("lists").(map[string]interface{}))
+				for _, p := range removedJSONPaths(old, attributes, lists, "", "") {
+					err := client.Delete(ctx, fmt.Sprintf("%s/%s", path, p))
+					if err != nil && !restconf.IsNotFound(err) {
+						return diag.Errorf("error deleting %s/%s. %s", path, p, err)
+					}
+				}
+			}
+		}
+		err = client.Patch(ctx, path, payload)
+	default:
+		err = client.Put(ctx, path, payload)
+	}
+
+	if err != nil {
+		return diag.Errorf("error updating %s. %s", path, err)
+	}
+
+	return resourceRestconfRead(ctx, d, meta)
+}
+
+func resourceRestconfDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	path := d.Id()
+
+	err = client.Delete(ctx, path)
+
+	if err != nil && !restconf.IsNotFound(err) {
+		return diag.Errorf("error deleting %s. %s", path, err)
+	}
+
+	return nil
+}
+
+func resourceRestconfImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	d.Set("method", "PUT")
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// restconfPayload wraps attributes in the top level member of the node at path.
+func restconfPayload(path string, attributes interface{}) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{restconfNodeName(path): attributes})
+}
+
+// restconfValue returns the content of the single top level member of a response.
+func restconfValue(body []byte) (interface{}, error) {
+	v, err := decodeJSON(body)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return nil, fmt.Errorf("expected a single top level member")
+	}
+	for _, value := range m {
+		return value, nil
+	}
+
+	return nil, nil
+}
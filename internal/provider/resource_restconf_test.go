@@ -0,0 +1,156 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestRestconf_basic(t *testing.T) {
+	rName := "iosxe_restconf"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+			testAccImportResourceFromExampleStep(rName, "attributes", "lists"),
+		},
+	})
+}
+
+func TestRestconf_mock(t *testing.T) {
+	rName := "iosxe_restconf"
+	srv := testAccMockDevice(t)
+	path := "Cisco-IOS-XE-native:native/ntp"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, path),
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+			{
+				// an entry added on the device is drift of a managed list
+				PreConfig: func() {
+					srv.Put(path+"/server/server-list=10.0.0.9", `{"Cisco-IOS-XE-ntp:server-list": {"ip-address": "10.0.0.9"}}`)
+				},
+				Config:             testAccExampleResourceConfig(rName),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccExampleResourceConfig(rName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, path+"/server/server-list=10.0.0.1"),
+					testAccCheckMockDestroy(srv, path+"/server/server-list=10.0.0.9"),
+				),
+			},
+			{
+				Config: testAccRestconfUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, path+"/server/server-list=10.0.0.3"),
+					testAccCheckMockDestroy(srv, path+"/server/server-list=10.0.0.1"),
+					testAccCheckMockDestroy(srv, path+"/server/server-list=10.0.0.2/prefer"),
+				),
+			},
+			testAccImportResourceFromExampleStep(rName, "attributes", "lists"),
+		},
+	})
+}
+
+const testAccRestconfUpdateConfig = `
+resource "iosxe_restconf" "example" {
+  path = "Cisco-IOS-XE-native:native/ntp"
+  attributes = jsonencode({
+    "Cisco-IOS-XE-ntp:server" = {
+      "server-list" = [
+        { "ip-address" = "10.0.0.2" },
+        { "ip-address" = "10.0.0.3" },
+      ]
+    }
+  })
+  lists = {
+    "server/server-list" = "ip-address"
+  }
+}
+`
+
+func TestRestconf_mockPatch(t *testing.T) {
+	srv := testAccMockDevice(t)
+	path := "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, path),
+		Steps: []resource.TestStep{
+			{
+				PreConfig: func() {
+					srv.Put("Cisco-IOS-XE-native:native/vrf", `{"Cisco-IOS-XE-native:vrf": {}}`)
+				},
+				Config: fmt.Sprintf(testAccRestconfPatchConfig, `, description = "FOO"`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, path+"/description"),
+				),
+			},
+			{
+				PreConfig: func() {
+					// config the device adds by itself is not drift
+					srv.Put(path+"/address-family", `{"Cisco-IOS-XE-native:address-family": {"ipv4": {}}}`)
+				},
+				Config:   fmt.Sprintf(testAccRestconfPatchConfig, `, description = "FOO"`),
+				PlanOnly: true,
+			},
+			{
+				Config: fmt.Sprintf(testAccRestconfPatchConfig, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockDeleted(srv, path+"/description"),
+					testAccCheckMockExists(srv, path+"/rd"),
+					testAccCheckMockExists(srv, path+"/address-family"),
+				),
+			},
+		},
+	})
+}
+
+const testAccRestconfPatchConfig = `
+resource "iosxe_restconf" "example" {
+  path       = "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR"
+  method     = "PATCH"
+  attributes = jsonencode({ name = "FOOBAR", rd = "1:1"%s })
+}
+`
+
+func TestRestconf_notFound(t *testing.T) {
+	testResourceReadNotFound(t, resourceRestconf(), "Cisco-IOS-XE-native:native/ntp")
+}
+
+func TestRestrictJSON(t *testing.T) {
+	dev, _ := decodeJSON([]byte(`{"Cisco-IOS-XE-ntp:server": {"server-list": [{"ip-address": "10.0.0.1", "version": 4}, {"ip-address": "10.0.0.9"}]}, "Cisco-IOS-XE-ntp:master": {"stratum": 3}}`))
+	cfg, _ := decodeJSON([]byte(`{"server": {"server-list": [{"ip-address": "10.0.0.1"}, {"ip-address": "10.0.0.2"}]}, "master": {"stratum": "3"}}`))
+
+	got := restrictJSON(dev, cfg, nil, "")
+	want, _ := decodeJSON([]byte(`{"server": {"server-list": [{"ip-address": "10.0.0.1"}]}, "master": {"stratum": "3"}}`))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unmanaged list: expected %v, got %v", want, got)
+	}
+
+	got = restrictJSON(dev, cfg, normalizeListPaths(map[string]interface{}{"Cisco-IOS-XE-ntp:server/server-list": "ip-address"}), "")
+	want, _ = decodeJSON([]byte(`{"server": {"server-list": [{"ip-address": "10.0.0.1"}, {"ip-address": "10.0.0.9"}]}, "master": {"stratum": "3"}}`))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("managed list: expected %v, got %v", want, got)
+	}
+}
+
+func TestRemovedJSONPaths(t *testing.T) {
+	old, _ := decodeJSON([]byte(`{"description": "foo", "rd": "1:1", "server": {"server-list": [{"ip-address": "10.0.0.1"}, {"ip-address": "2001:db8::1"}]}}`))
+	new, _ := decodeJSON([]byte(`{"rd": "1:1", "server": {"server-list": [{"ip-address": "10.0.0.1"}]}}`))
+	lists := normalizeListPaths(map[string]interface{}{"server/server-list": "ip-address"})
+
+	got := removedJSONPaths(old, new, lists, "", "")
+	want := []string{"description", "server/server-list=2001:db8::1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,65 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceSaveConfig() *schema.Resource {
+	return &schema.Resource{
+		Description: "Save the running config to the startup config.",
+
+		CreateContext: resourceSaveConfigCreate,
+		ReadContext:   schema.NoopContext,
+		DeleteContext: resourceSaveConfigDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"device": deviceSchema(),
+			"triggers": {
+				Description: "Arbitrary values that save the config again when changed, e.g. the IDs of the resources to save.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"result": {
+				Description: "Result reported by the device.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceSaveConfigCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := meta.(*apiClient).restconfClient(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	result, err := saveConfig(ctx, client)
+
+	if err != nil {
+		return diag.Errorf("error saving config. %s", err)
+	}
+
+	d.SetId(resource.UniqueId())
+	d.Set("result", result)
+
+	return nil
+}
+
+func resourceSaveConfigDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// the startup config is left as it is
+	return nil
+}
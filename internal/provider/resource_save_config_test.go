@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf/restconftest"
+)
+
+func TestSaveConfig_basic(t *testing.T) {
+	rName := "iosxe_save_config"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+		},
+	})
+}
+
+func TestSaveConfig_mock(t *testing.T) {
+	srv := testAccMockDevice(t)
+	testAccMockSaveConfig(srv)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccSaveConfigConfig, "IoT"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("iosxe_save_config.example", "result", "Save running-config successful"),
+					testAccCheckMockSaves(srv, 1),
+					testAccCheckMockSavedLast(srv),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccSaveConfigConfig, "IoT"),
+				Check:  testAccCheckMockSaves(srv, 1),
+			},
+			{
+				Config: fmt.Sprintf(testAccSaveConfigConfig, "Cameras"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockSaves(srv, 2),
+					testAccCheckMockSavedLast(srv),
+				),
+			},
+		},
+	})
+}
+
+const testAccSaveConfigConfig = `
+resource "iosxe_l2_vlan" "example" {
+  vlanid = 420
+  name   = "%s"
+}
+
+resource "iosxe_save_config" "example" {
+  triggers = {
+    vlan = iosxe_l2_vlan.example.name
+  }
+}
+`
+
+func testAccCheckMockSaves(srv *restconftest.Server, n int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if got := testAccMockSaves(srv); got != n {
+			return fmt.Errorf("expected %d saves, got %d", n, got)
+		}
+		return nil
+	}
+}
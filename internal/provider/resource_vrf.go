@@ -2,11 +2,14 @@ package provider
 
 import (
 	"context"
+	"log"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/poroping/go-ios-xe-sdk/models"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
 )
 
 func resourceVRF() *schema.Resource {
@@ -18,7 +21,21 @@ func resourceVRF() *schema.Resource {
 		UpdateContext: resourceVRFUpdate,
 		DeleteContext: resourceVRFDelete,
 
+		CustomizeDiff: resourceVRFCustomizeDiff,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateDevice(schema.ImportStatePassthroughContext),
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
+			"device": deviceSchema(),
 			"address_family": {
 				Description: "VRF address family.",
 				Type:        schema.TypeList,
@@ -26,7 +43,7 @@ func resourceVRF() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"ip_version": {
-							Description: "Address family version.",
+							Description: "Address family version. IPv6 needs IOS-XE 16.9 or later.",
 							Type:        schema.TypeInt,
 							Required:    true,
 						},
@@ -85,8 +102,23 @@ func resourceVRF() *schema.Resource {
 	}
 }
 
+// resourceVRFCustomizeDiff rejects address families the device can't
+// configure.
+func resourceVRFCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	for _, af := range d.Get("address_family").([]interface{}) {
+		if af, ok := af.(map[string]interface{}); ok && af["ip_version"] == 6 {
+			return requireVersion(ctx, d, meta, "16.9", "IPv6 VRF address families")
+		}
+	}
+	return nil
+}
+
 func resourceVRFCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("name").(string)
 
 	params := models.VRFDefinition{}
@@ -94,7 +126,7 @@ func resourceVRFCreate(ctx context.Context, d *schema.ResourceData, meta interfa
 
 	getCreateUpdateVRFObject(d, &params.VRFDefinition)
 
-	err := client.CreateVRF(params)
+	err = client.CreateVRF(params)
 
 	if err != nil {
 		return diag.Errorf("error creating Vlan. %s", err)
@@ -106,8 +138,12 @@ func resourceVRFCreate(ctx context.Context, d *schema.ResourceData, meta interfa
 }
 
 func resourceVRFRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
-	id := d.Get("name").(string)
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id := d.Id()
 
 	params := models.VRFDefinition{}
 	params.VRFDefinition.Name = id
@@ -115,18 +151,25 @@ func resourceVRFRead(ctx context.Context, d *schema.ResourceData, meta interface
 	resp, err := client.ReadVRF(params)
 
 	if err != nil {
+		if restconf.IsNotFound(err) {
+			log.Printf("[WARN] VRF %s not found, removing from state", id)
+			d.SetId("")
+			return nil
+		}
 		return diag.Errorf("error retrieving Vlan. %s", err)
 	}
 
 	resourceSetVRF(d, &resp.VRFDefinition)
 
-	d.SetId(id)
-
 	return nil
 }
 
 func resourceVRFUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("name").(string)
 
 	params := models.VRFDefinition{}
@@ -134,7 +177,7 @@ func resourceVRFUpdate(ctx context.Context, d *schema.ResourceData, meta interfa
 
 	getCreateUpdateVRFObject(d, &params.VRFDefinition)
 
-	err := client.UpdateVRF(params)
+	err = client.UpdateVRF(params)
 
 	if err != nil {
 		return diag.Errorf("error updating Vlan. %s", err)
@@ -146,13 +189,17 @@ func resourceVRFUpdate(ctx context.Context, d *schema.ResourceData, meta interfa
 }
 
 func resourceVRFDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	client := meta.(*apiClient).Client
+	client, err := meta.(*apiClient).deviceClient(ctx, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	id := d.Get("name").(string)
 
 	params := models.VRFDefinition{}
 	params.VRFDefinition.Name = id
 
-	err := client.DeleteVRF(params)
+	err = client.DeleteVRF(params)
 
 	if err != nil {
 		return diag.Errorf("error deleting Vlan. %s", err)
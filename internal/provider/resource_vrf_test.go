@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -15,6 +16,79 @@ func TestVRF_basic(t *testing.T) {
 		// CheckDestroy: testAccCheckExampleResourceDestroy,
 		Steps: []resource.TestStep{
 			testAccCreateResourceFromExampleStep(rName),
+			testAccImportResourceFromExampleStep(rName),
 		},
 	})
 }
+
+func TestVRF_mock(t *testing.T) {
+	rName := "iosxe_vrf"
+	srv := testAccMockDevice(t)
+	path := "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR"
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		CheckDestroy:      testAccCheckMockDestroy(srv, path),
+		Steps: []resource.TestStep{
+			testAccCreateResourceFromExampleStep(rName),
+			{
+				Config: testAccVRFUpdateConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockExists(srv, path),
+					resource.TestCheckResourceAttr("iosxe_vrf.example", "description", "UPDATED"),
+					resource.TestCheckResourceAttr("iosxe_vrf.example", "rd", "566:4561"),
+					resource.TestCheckResourceAttr("iosxe_vrf.example", "route_target.#", "1"),
+				),
+			},
+			testAccImportResourceFromExampleStep(rName),
+		},
+	})
+}
+
+const testAccVRFUpdateConfig = `
+resource "iosxe_vrf" "example" {
+  name        = "FOOBAR"
+  description = "UPDATED"
+  rd          = "566:4561"
+
+  address_family {
+    ip_version = 4
+  }
+
+  route_target {
+    community = "export"
+    rt        = "6969:111"
+  }
+}
+`
+
+func TestVRF_notFound(t *testing.T) {
+	testResourceReadNotFound(t, resourceVRF(), "FOOBAR")
+}
+
+func TestVRF_mockIPv6OldVersion(t *testing.T) {
+	srv := testAccMockDevice(t)
+	srv.Put("Cisco-IOS-XE-native:native/version", `{"Cisco-IOS-XE-native:version": "16.6"}`)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: providerFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccVRFIPv6Config,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`IPv6 VRF address families need IOS-XE 16.9 or later, the device runs 16.6`),
+			},
+		},
+	})
+}
+
+const testAccVRFIPv6Config = `
+resource "iosxe_vrf" "example" {
+  name = "FOOBAR"
+  rd   = "566:4560"
+
+  address_family {
+    ip_version = 6
+  }
+}
+`
@@ -0,0 +1,370 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// decodeJSON decodes b keeping numbers as json.Number, so large integers and
+// their formatting survive a round trip.
+func decodeJSON(b []byte) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func encodeJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// suppressEquivalentJSON hides diffs between JSON documents that only differ
+// in formatting or key order.
+func suppressEquivalentJSON(k, old, new string, d *schema.ResourceData) bool {
+	o, err := decodeJSON([]byte(old))
+	if err != nil {
+		return false
+	}
+	n, err := decodeJSON([]byte(new))
+	if err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(o, n)
+}
+
+// restconfNodeName returns the module qualified name of the node at path, as
+// used for the top level member of its payload. An unqualified last segment
+// takes the module of the closest qualified segment before it.
+func restconfNodeName(path string) string {
+	module := ""
+	name := ""
+	for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		if i := strings.Index(seg, "="); i >= 0 {
+			seg = seg[:i]
+		}
+		if i := strings.Index(seg, ":"); i >= 0 {
+			module = seg[:i]
+			seg = seg[i+1:]
+		}
+		name = seg
+	}
+	if module == "" {
+		return name
+	}
+
+	return module + ":" + name
+}
+
+// restconfChildName returns the name of the node at path as a member of its
+// parent, qualified only if the path qualifies it.
+func restconfChildName(path string) string {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	name := segs[len(segs)-1]
+	if i := strings.Index(name, "="); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// isListEntryPath reports whether the last segment of path selects a list entry.
+func isListEntryPath(path string) bool {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	return strings.Contains(segs[len(segs)-1], "=")
+}
+
+func parentPath(path string) string {
+	path = strings.Trim(path, "/")
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func localJSONName(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// jsonMember finds the member of m matching name regardless of module prefix.
+func jsonMember(m map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if localJSONName(k) == localJSONName(name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// normalizeListPaths returns the managed lists keyed by their path in local
+// names, with the key leaves split out.
+func normalizeListPaths(lists map[string]interface{}) map[string][]string {
+	r := map[string][]string{}
+	for path, keys := range lists {
+		segs := strings.Split(strings.Trim(path, "/"), "/")
+		for i := range segs {
+			segs[i] = localJSONName(segs[i])
+		}
+		k := []string{}
+		for _, key := range strings.Split(keys.(string), ",") {
+			k = append(k, strings.TrimSpace(key))
+		}
+		r[strings.Join(segs, "/")] = k
+	}
+
+	return r
+}
+
+func joinJSONPath(at string, name string) string {
+	if at == "" {
+		return localJSONName(name)
+	}
+	return at + "/" + localJSONName(name)
+}
+
+func scalarEqual(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	switch a.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	switch b.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	if a == nil || b == nil {
+		return false
+	}
+
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// sameListKeys reports whether the entries a and b have equal values for all keys.
+func sameListKeys(a, b interface{}, keys []string) bool {
+	am, ok := a.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	bm, ok := b.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, k := range keys {
+		av, ok := jsonMember(am, k)
+		if !ok {
+			return false
+		}
+		bv, ok := jsonMember(bm, k)
+		if !ok || !scalarEqual(av, bv) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// restrictJSON reduces the device value dev to what is managed by the
+// configured value cfg, so that config the device adds by itself does not
+// show as drift. Values equal to the config are returned in the config's
+// spelling. Entries of the managed lists are matched on their keys and extra
+// entries on the device are kept, other lists only keep the configured
+// entries found on the device.
+func restrictJSON(dev interface{}, cfg interface{}, lists map[string][]string, at string) interface{} {
+	switch c := cfg.(type) {
+	case map[string]interface{}:
+		d, ok := dev.(map[string]interface{})
+		if !ok {
+			return dev
+		}
+		r := map[string]interface{}{}
+		for k, cv := range c {
+			dv, ok := jsonMember(d, k)
+			if !ok {
+				continue
+			}
+			r[k] = restrictJSON(dv, cv, lists, joinJSONPath(at, k))
+		}
+		return r
+	case []interface{}:
+		d, ok := dev.([]interface{})
+		if !ok {
+			return dev
+		}
+		if keys, ok := lists[at]; ok {
+			r := []interface{}{}
+			matched := make([]bool, len(d))
+			for _, ce := range c {
+				for i, de := range d {
+					if !matched[i] && sameListKeys(de, ce, keys) {
+						matched[i] = true
+						r = append(r, restrictJSON(de, ce, lists, at))
+						break
+					}
+				}
+			}
+			for i, de := range d {
+				if !matched[i] {
+					r = append(r, de)
+				}
+			}
+			return r
+		}
+		if isScalarList(c) {
+			if len(c) == len(d) && containsAll(d, c) && containsAll(c, d) {
+				return c
+			}
+			return d
+		}
+		r := []interface{}{}
+		for _, ce := range c {
+			for _, de := range d {
+				if reflect.DeepEqual(restrictJSON(de, ce, lists, at), ce) {
+					r = append(r, ce)
+					break
+				}
+			}
+		}
+		return r
+	}
+
+	if scalarEqual(dev, cfg) {
+		return cfg
+	}
+	return dev
+}
+
+func isScalarList(l []interface{}) bool {
+	for _, e := range l {
+		switch e.(type) {
+		case map[string]interface{}, []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+func containsAll(l []interface{}, values []interface{}) bool {
+	for _, v := range values {
+		found := false
+		for _, e := range l {
+			if scalarEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// removedJSONPaths returns the RESTCONF paths, relative to the node, of the
+// members and managed list entries in old that are gone from new. Only lists
+// reached through containers can be addressed.
+func removedJSONPaths(old interface{}, new interface{}, lists map[string][]string, at string, prefix string) []string {
+	r := []string{}
+
+	switch o := old.(type) {
+	case map[string]interface{}:
+		n, ok := new.(map[string]interface{})
+		if !ok {
+			return r
+		}
+		names := make([]string, 0, len(o))
+		for k := range o {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			p := k
+			if prefix != "" {
+				p = prefix + "/" + k
+			}
+			nv, ok := jsonMember(n, k)
+			if !ok {
+				r = append(r, p)
+				continue
+			}
+			r = append(r, removedJSONPaths(o[k], nv, lists, joinJSONPath(at, k), p)...)
+		}
+	case []interface{}:
+		keys, ok := lists[at]
+		if !ok {
+			return r
+		}
+		n, _ := new.([]interface{})
+		for _, oe := range o {
+			found := false
+			for _, ne := range n {
+				if sameListKeys(oe, ne, keys) {
+					found = true
+					break
+				}
+			}
+			if found {
+				continue
+			}
+			m, _ := oe.(map[string]interface{})
+			values := []string{}
+			for _, k := range keys {
+				v, _ := jsonMember(m, k)
+				values = append(values, url.PathEscape(fmt.Sprint(v)))
+			}
+			r = append(r, fmt.Sprintf("%s=%s", prefix, strings.Join(values, ",")))
+		}
+	}
+
+	return r
+}
+
+// flattenJSON adds the leaves of v to out, keyed by their dotted path below
+// prefix in local names. List entries are keyed by their index.
+func flattenJSON(v interface{}, prefix string, out map[string]string) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			flattenJSON(e, join(localJSONName(k)), out)
+		}
+	case []interface{}:
+		for i, e := range t {
+			flattenJSON(e, join(strconv.Itoa(i)), out)
+		}
+	case nil:
+		// empty leaves, [null], are present without a value
+		out[prefix] = ""
+	default:
+		out[prefix] = fmt.Sprint(t)
+	}
+}
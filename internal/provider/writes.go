@@ -0,0 +1,479 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/poroping/terraform-provider-iosxe/internal/netconf"
+	"github.com/poroping/terraform-provider-iosxe/internal/restconf"
+)
+
+const (
+	saveConfigRPC = "cisco-ia:save-config"
+	checkpointRPC = "cisco-ia:checkpoint"
+	rollbackRPC   = "cisco-ia:rollback"
+
+	// checkpointURL is where cisco-ia:checkpoint keeps the running config
+	checkpointURL = "flash:ia_checkpoint"
+)
+
+// deviceWrites counts the resource writes in flight to a device and whether
+// any of them changed the running config since the last save.
+type deviceWrites struct {
+	inFlight int
+	unsaved  bool
+
+	checkpoint    sync.Once
+	checkpointErr error
+	rolledBack    bool
+
+	// staged are the resources written to the candidate datastore since the
+	// last commit, those not deleted are read back before the next one is
+	// confirmed
+	staged []writtenResource
+	// planned is set once a write to the device is planned, so that its
+	// iosxe_commit is planned to commit it
+	planned bool
+	// commitFailed is set once a staged write or a commit failed, nothing is
+	// committed to the device for the rest of the run
+	commitFailed bool
+	// commitMu is held shared by the writes staging in the candidate
+	// datastore and exclusively by the commits
+	commitMu sync.RWMutex
+}
+
+// writtenResource is a resource read back before confirming the commit.
+type writtenResource struct {
+	name     string
+	resource *schema.Resource
+	state    *terraform.InstanceState
+	deleted  bool
+}
+
+// stagingClients are the clients with confirmed_commit configured in this
+// process, whose staged writes CommitStaged commits.
+var stagingClients struct {
+	sync.Mutex
+	clients []*apiClient
+}
+
+// trackWrites wraps the create, update and delete functions of the resource
+// name so that save_config_on_apply can save once the writes to a device are
+// done, rollback_on_failure can roll the device back when one of them fails,
+// and confirmed_commit can stage them for iosxe_commit. Its CustomizeDiff
+// records the planned writes for iosxe_commit.
+func trackWrites(name string, r *schema.Resource) {
+	r.CreateContext = trackWrite(name, r, r.CreateContext, false)
+	r.UpdateContext = trackWrite(name, r, r.UpdateContext, false)
+	r.DeleteContext = trackWrite(name, r, r.DeleteContext, true)
+
+	next := r.CustomizeDiff
+	r.CustomizeDiff = func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		c, ok := meta.(*apiClient)
+		if ok && c.confirmedCommit && d.NewValueKnown("device") && (d.Id() == "" || len(d.GetChangedKeysPrefix("")) > 0) {
+			c.planWrite(d.Get("device").(string))
+		}
+		if next != nil {
+			return next(ctx, d, meta)
+		}
+		return nil
+	}
+}
+
+// trackWrite wraps f, which deletes the resource if deletes is set. With
+// confirmed_commit the write is staged in the candidate datastore until
+// iosxe_commit commits it, or CommitStaged when the provider exits, and the
+// resource is read back before the commit is confirmed unless it was deleted.
+func trackWrite(name string, r *schema.Resource, f func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics, deletes bool) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+	if f == nil {
+		return nil
+	}
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		c := meta.(*apiClient)
+		device := d.Get("device").(string)
+
+		var diags diag.Diagnostics
+		if err := c.beginWrite(ctx, d, device); err != nil {
+			diags = diag.FromErr(err)
+		} else if c.confirmedCommit {
+			diags = c.stageWrite(ctx, device, d, meta, f, name, r, deletes)
+		} else {
+			diags = f(ctx, d, meta)
+		}
+
+		save, rollback := c.endWrite(device, diags.HasError())
+		if rollback {
+			diags = append(diags, c.rollback(ctx, d, device)...)
+		}
+		if save {
+			if err := c.saveConfig(ctx, d); err != nil {
+				diags = append(diags, diag.Errorf("error saving config. %s", err)...)
+			}
+		}
+
+		return diags
+	}
+}
+
+// deviceWrites returns the writes to device, the caller holds c.mu.
+func (c *apiClient) deviceWrites(device string) *deviceWrites {
+	w, ok := c.writes[device]
+	if !ok {
+		w = &deviceWrites{}
+		c.writes[device] = w
+	}
+	return w
+}
+
+// beginWrite registers a write to device. With rollback_on_failure the first
+// write takes the checkpoint and the others wait for it.
+func (c *apiClient) beginWrite(ctx context.Context, d *schema.ResourceData, device string) error {
+	c.mu.Lock()
+	w := c.deviceWrites(device)
+	w.inFlight++
+	w.unsaved = true
+	rolledBack := w.rolledBack
+	c.mu.Unlock()
+
+	if rolledBack {
+		return fmt.Errorf("the device was rolled back after an earlier failure in this run")
+	}
+	if !c.rollbackOnFailure {
+		return nil
+	}
+
+	w.checkpoint.Do(func() {
+		w.checkpointErr = c.takeCheckpoint(ctx, d)
+	})
+	if w.checkpointErr != nil {
+		return fmt.Errorf("error taking checkpoint. %s", w.checkpointErr)
+	}
+
+	return nil
+}
+
+// endWrite reports whether the config has to be saved, which is the case
+// when save_config_on_apply is set, no other write to the device is in flight
+// and the write is not staged for a commit saving it, and whether the device has to be rolled back, which is the case for
+// the first failed write with rollback_on_failure set. Writes finishing later
+// save again.
+func (c *apiClient) endWrite(device string, failed bool) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := c.writes[device]
+	w.inFlight--
+
+	rollback := false
+	if failed && c.rollbackOnFailure && !w.rolledBack && w.checkpointErr == nil {
+		w.rolledBack = true
+		rollback = true
+	}
+
+	if !c.saveConfigOnApply || c.confirmedCommit || w.inFlight > 0 || !w.unsaved {
+		return false, rollback
+	}
+	w.unsaved = false
+
+	return true, rollback
+}
+
+// planWrite records a planned write to device.
+func (c *apiClient) planWrite(device string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.deviceWrites(device).planned = true
+}
+
+// writesPlanned reports whether a write to device was planned.
+func (c *apiClient) writesPlanned(device string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.deviceWrites(device).planned
+}
+
+// stageWrite runs the write f, staging it in the candidate datastore of
+// device. A failed write discards the writes staged with it.
+func (c *apiClient) stageWrite(ctx context.Context, device string, d *schema.ResourceData, meta interface{}, f func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics, name string, r *schema.Resource, deletes bool) diag.Diagnostics {
+	c.mu.Lock()
+	w := c.deviceWrites(device)
+	c.mu.Unlock()
+
+	w.commitMu.RLock()
+
+	c.mu.Lock()
+	failed := w.commitFailed
+	c.mu.Unlock()
+
+	if failed {
+		w.commitMu.RUnlock()
+		return diag.Errorf("a write to the device or its commit failed earlier in this run, nothing more is committed to it")
+	}
+
+	written := writtenResource{name: name, resource: r, deleted: deletes}
+	if deletes {
+		written.state = d.State()
+	}
+	diags := f(ctx, d, meta)
+	if !deletes {
+		written.state = d.State()
+	}
+
+	c.mu.Lock()
+	if diags.HasError() {
+		w.commitFailed = true
+		w.staged = nil
+	} else if written.state != nil {
+		w.staged = append(w.staged, written)
+	}
+	t := c.transports[device]
+	c.mu.Unlock()
+
+	w.commitMu.RUnlock()
+
+	if diags.HasError() {
+		if t != nil {
+			t.Cancel()
+		}
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Discarded the writes staged in the candidate datastore",
+			Detail:   "Nothing more is committed to the device in this run.",
+		})
+	}
+
+	return diags
+}
+
+// commitStaged commits the writes staged in the candidate datastore of device
+// with a single confirmed commit and returns the number of resources written
+// with it.
+func (c *apiClient) commitStaged(ctx context.Context, device string) (int, error) {
+	c.mu.Lock()
+	w := c.deviceWrites(device)
+	c.mu.Unlock()
+
+	w.commitMu.Lock()
+	defer w.commitMu.Unlock()
+
+	c.mu.Lock()
+	failed := w.commitFailed
+	staged := w.staged
+	w.staged = nil
+	t := c.transports[device]
+	c.mu.Unlock()
+
+	if failed {
+		return 0, fmt.Errorf("a write to the device or its commit failed earlier in this run, the writes staged with it were discarded")
+	}
+
+	err := c.commitConfirmed(ctx, t, staged)
+	if err != nil {
+		c.mu.Lock()
+		w.commitFailed = true
+		c.mu.Unlock()
+		return 0, err
+	}
+
+	return len(staged), nil
+}
+
+// commitConfirmed commits the writes held by t with a confirmed commit and
+// confirms it once every written resource reads back. Otherwise the writes
+// are discarded, and the commit is reverted if it was made.
+func (c *apiClient) commitConfirmed(ctx context.Context, t *netconf.Transport, written []writtenResource) error {
+	if t == nil {
+		return nil
+	}
+
+	if err := t.CommitConfirmed(ctx, c.confirmTimeout); err != nil {
+		t.Cancel()
+		return err
+	}
+
+	for _, w := range written {
+		if w.deleted {
+			continue
+		}
+		d := w.resource.Data(w.state)
+		diags := w.resource.ReadContext(ctx, d, c)
+		if diags.HasError() {
+			t.Cancel()
+			msgs := []string{}
+			for _, e := range diags {
+				msgs = append(msgs, e.Summary)
+			}
+			return fmt.Errorf("unable to read %s %s after the commit, it is reverted. %s", w.name, w.state.ID, strings.Join(msgs, ", "))
+		}
+		if d.Id() == "" {
+			t.Cancel()
+			return fmt.Errorf("%s %s is missing after the commit, it is reverted", w.name, w.state.ID)
+		}
+	}
+
+	if err := t.Confirm(ctx); err != nil {
+		t.Cancel()
+		return err
+	}
+	log.Printf("[INFO] confirmed the commit of %d resources", len(written))
+
+	return nil
+}
+
+// registerStaging records c for CommitStaged.
+func registerStaging(c *apiClient) {
+	stagingClients.Lock()
+	defer stagingClients.Unlock()
+
+	stagingClients.clients = append(stagingClients.clients, c)
+}
+
+// CommitStaged commits the writes still staged in the candidate datastores
+// when the provider exits, such as those of a configuration without
+// iosxe_commit or written after it. Terraform has no hook at the end of an
+// apply and gives the provider little time to exit, so failures are only
+// logged and an unconfirmed commit is reverted by the device.
+func CommitStaged() {
+	stagingClients.Lock()
+	clients := stagingClients.clients
+	stagingClients.clients = nil
+	stagingClients.Unlock()
+
+	ctx := context.Background()
+	for _, c := range clients {
+		c.mu.Lock()
+		devices := []string{}
+		for device, w := range c.writes {
+			if len(w.staged) > 0 && !w.commitFailed {
+				devices = append(devices, device)
+			}
+		}
+		c.mu.Unlock()
+
+		for _, device := range devices {
+			n, err := c.commitStaged(ctx, device)
+			if err != nil {
+				log.Printf("[ERROR] unable to commit the writes staged for device %q. %s", device, err)
+				continue
+			}
+			log.Printf("[INFO] committed %d resources staged for device %q", n, device)
+			if !c.saveConfigOnApply {
+				continue
+			}
+			client, err := c.deviceRestconfClient(device)
+			if err == nil {
+				_, err = saveConfig(ctx, client)
+			}
+			if err != nil {
+				log.Printf("[ERROR] unable to save the config of device %q. %s", device, err)
+			}
+		}
+	}
+}
+
+func (c *apiClient) saveConfig(ctx context.Context, d *schema.ResourceData) error {
+	client, err := c.restconfClient(d)
+	if err != nil {
+		return err
+	}
+
+	result, err := saveConfig(ctx, client)
+	if err != nil {
+		return err
+	}
+	log.Printf("[INFO] %s", result)
+
+	return nil
+}
+
+func (c *apiClient) takeCheckpoint(ctx context.Context, d *schema.ResourceData) error {
+	client, err := c.restconfClient(d)
+	if err != nil {
+		return err
+	}
+
+	result, err := iaRPC(ctx, client, checkpointRPC, nil)
+	if err != nil {
+		return err
+	}
+	log.Printf("[INFO] checkpoint taken: %s", result)
+
+	return nil
+}
+
+// rollback restores the checkpoint and reports the outcome as a diagnostic.
+// A rolled back device has to be saved again if the failed run saved it.
+func (c *apiClient) rollback(ctx context.Context, d *schema.ResourceData, device string) diag.Diagnostics {
+	client, err := c.restconfClient(d)
+	if err == nil {
+		var result string
+		result, err = iaRPC(ctx, client, rollbackRPC, map[string]interface{}{
+			"target-url": checkpointURL,
+			"verbose":    true,
+		})
+		if err == nil {
+			c.mu.Lock()
+			c.writes[device].unsaved = true
+			c.mu.Unlock()
+
+			return diag.Diagnostics{
+				{
+					Severity: diag.Warning,
+					Summary:  "Rolled back to the checkpoint taken before the first write",
+					Detail:   result,
+				},
+			}
+		}
+	}
+
+	return diag.Errorf("error rolling back to the checkpoint, the device is left as it is. %s", err)
+}
+
+// saveConfig copies the running config to the startup config and returns the
+// result reported by the device.
+func saveConfig(ctx context.Context, client *restconf.Client) (string, error) {
+	return iaRPC(ctx, client, saveConfigRPC, nil)
+}
+
+// iaRPC invokes a cisco-ia operation and returns its result.
+func iaRPC(ctx context.Context, client *restconf.Client, rpc string, input map[string]interface{}) (string, error) {
+	var body []byte
+	if input != nil {
+		b, err := json.Marshal(map[string]interface{}{"cisco-ia:input": input})
+		if err != nil {
+			return "", err
+		}
+		body = b
+	}
+
+	resp, err := client.Post(ctx, rpc, body)
+	if err != nil {
+		return "", err
+	}
+
+	output := map[string]struct {
+		Result  string `json:"result"`
+		Message string `json:"message"`
+	}{}
+	if len(resp) > 0 {
+		if err := json.Unmarshal(resp, &output); err != nil {
+			return "", fmt.Errorf("unable to decode %s output. %s", rpc, err)
+		}
+	}
+
+	o := output["cisco-ia:output"]
+	if o.Message != "" {
+		return fmt.Sprintf("%s %s", o.Result, o.Message), nil
+	}
+
+	return o.Result, nil
+}
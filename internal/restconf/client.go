@@ -0,0 +1,185 @@
+package restconf
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Options configures the HTTP client used for every request to a device.
+type Options struct {
+	Insecure              bool
+	MaxRetries            int
+	RetryBackoff          time.Duration
+	RequestTimeout        time.Duration
+	MaxConcurrentRequests int
+	// TLSConfig configures HTTPS connections, Insecure skips the verification
+	// of the device certificate regardless.
+	TLSConfig *tls.Config
+	// Proxy is the proxy of HTTPS requests, the one of the environment if nil.
+	Proxy *url.URL
+	// Transport sends the requests, an HTTPS transport honouring the options
+	// above if nil.
+	Transport http.RoundTripper
+	// Token authenticates the HTTPS requests as bearer token instead of the
+	// username and password, it is not sent through Transport.
+	Token string
+}
+
+// NewHTTPClient returns a client with its own connection pool that logs every
+// request, limits concurrency, retries busy responses and reports missing data
+// as NotFoundError.
+func NewHTTPClient(o Options) *http.Client {
+	rt := o.Transport
+	if rt == nil {
+		tlsConfig := &tls.Config{}
+		if o.TLSConfig != nil {
+			tlsConfig = o.TLSConfig.Clone()
+		}
+		tlsConfig.InsecureSkipVerify = o.Insecure
+		proxy := http.ProxyFromEnvironment
+		if o.Proxy != nil {
+			proxy = http.ProxyURL(o.Proxy)
+		}
+		rt = &http.Transport{
+			Proxy:               proxy,
+			TLSClientConfig:     tlsConfig,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		}
+		if o.Token != "" {
+			rt = TokenTransport(rt, o.Token)
+		}
+	}
+
+	rt = LogTransport(rt)
+	if o.MaxConcurrentRequests > 0 {
+		rt = LimitTransport(rt, o.MaxConcurrentRequests)
+	}
+	rt = RetryTransport(rt, o.MaxRetries, o.RetryBackoff, o.RequestTimeout)
+	rt = NotFoundTransport(rt)
+
+	return &http.Client{Transport: rt}
+}
+
+// WithContext returns a copy of c whose requests are bound to ctx, so that
+// cancelling ctx or reaching its deadline aborts requests built without one.
+func WithContext(ctx context.Context, c *http.Client) *http.Client {
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	cc := *c
+	cc.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return next.RoundTrip(req.WithContext(ctx))
+	})
+
+	return &cc
+}
+
+const (
+	// DataPath is the root of the RESTCONF datastore resource.
+	DataPath = "/restconf/data/"
+	// OperationsPath is the root of the RESTCONF operations resource.
+	OperationsPath = "/restconf/operations/"
+
+	contentType = "application/yang-data+json"
+)
+
+// Client sends RESTCONF requests for arbitrary paths, for models
+// go-ios-xe-sdk has no wrapper for. Paths are relative to DataPath.
+type Client struct {
+	HTTPClient *http.Client
+	Host       string
+	Username   string
+	Password   string
+	UserAgent  string
+}
+
+// Get returns the body of a GET of path, or a NotFoundError if there is no
+// data at path.
+func (c *Client) Get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, DataPath+path, query, nil)
+}
+
+// Put creates or replaces the data at path.
+func (c *Client) Put(ctx context.Context, path string, body []byte) error {
+	_, err := c.do(ctx, http.MethodPut, DataPath+path, nil, body)
+	return err
+}
+
+// Patch merges body into the existing data at path.
+func (c *Client) Patch(ctx context.Context, path string, body []byte) error {
+	_, err := c.do(ctx, http.MethodPatch, DataPath+path, nil, body)
+	return err
+}
+
+// Delete deletes the data at path, or returns a NotFoundError if there is none.
+func (c *Client) Delete(ctx context.Context, path string) error {
+	_, err := c.do(ctx, http.MethodDelete, DataPath+path, nil, nil)
+	return err
+}
+
+// Post invokes the operation, e.g. "Cisco-IOS-XE-rpc:reload", and returns
+// its output, which is empty for operations without one.
+func (c *Client) Post(ctx context.Context, operation string, body []byte) ([]byte, error) {
+	return c.do(ctx, http.MethodPost, OperationsPath+operation, nil, body)
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body []byte) ([]byte, error) {
+	u := url.URL{
+		Scheme:   "https",
+		Host:     c.Host,
+		Path:     path,
+		RawQuery: query.Encode(),
+	}
+	// keys in the path are already escaped
+	if p, err := url.PathUnescape(path); err == nil {
+		u.Path = p
+		u.RawPath = path
+	}
+
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	req.SetBasicAuth(c.Username, c.Password)
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, &NotFoundError{Path: path}
+	case res.StatusCode >= 300:
+		return nil, newError(res, b)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,77 @@
+package restconf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// NotFoundError is returned when the device holds no data at the requested path.
+type NotFoundError struct {
+	Path string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("no data found at %s", e.Path)
+}
+
+// IsNotFound reports whether err, or any error it wraps, is a NotFoundError.
+func IsNotFound(err error) bool {
+	var nf *NotFoundError
+	return errors.As(err, &nf)
+}
+
+// Error is a RESTCONF error response from the device.
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Tag        string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s %s failed with status %d", e.Method, e.Path, e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s failed with status %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// newError builds an Error from a response, decoding the ietf-restconf errors
+// body when there is one.
+func newError(res *http.Response, body []byte) error {
+	e := &Error{
+		Method:     res.Request.Method,
+		Path:       res.Request.URL.Path,
+		StatusCode: res.StatusCode,
+	}
+
+	var r struct {
+		Errors struct {
+			Error []struct {
+				Tag     string `json:"error-tag"`
+				Message string `json:"error-message"`
+			} `json:"error"`
+		} `json:"ietf-restconf:errors"`
+		// IOS-XE omits the module name on some releases
+		ErrorsUnqualified struct {
+			Error []struct {
+				Tag     string `json:"error-tag"`
+				Message string `json:"error-message"`
+			} `json:"error"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &r) == nil {
+		errs := append(r.Errors.Error, r.ErrorsUnqualified.Error...)
+		if len(errs) > 0 {
+			e.Tag = errs[0].Tag
+			e.Message = errs[0].Message
+		}
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	return e
+}
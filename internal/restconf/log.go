@@ -0,0 +1,125 @@
+package restconf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// LogSubsystem is the tflog subsystem of the request logs. Its level follows
+// TF_LOG_PROVIDER unless set with TF_LOG_PROVIDER_IOSXE_RESTCONF.
+const LogSubsystem = "restconf"
+
+var (
+	// secretMember matches the names of payload members holding secrets
+	secretMember = regexp.MustCompile(`(?i)password|secret|key-string|shared-key`)
+	// secretWord matches secrets in CLI lines, e.g. "password 7 0822455D0A16"
+	secretWord = regexp.MustCompile(`(?i)\b(password|secret|key-string|pre-shared-key)((?:\s+\d)?\s+)\S+`)
+)
+
+// LogTransport logs every request with its response under LogSubsystem at
+// debug level: host, method, path, status, duration and the bodies, with secrets
+// masked.
+func LogTransport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := logContext(req.Context())
+		fields := map[string]interface{}{
+			"host":   req.URL.Host,
+			"method": req.Method,
+			"path":   req.URL.EscapedPath(),
+		}
+		if req.URL.RawQuery != "" {
+			fields["query"] = req.URL.RawQuery
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			body, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			fields["request_body"] = MaskSecrets(body)
+		}
+
+		start := time.Now()
+		res, err := next.RoundTrip(req)
+		fields["duration"] = time.Since(start).String()
+		if err != nil {
+			fields["error"] = err.Error()
+			tflog.SubsystemDebug(ctx, LogSubsystem, "request failed", fields)
+			return nil, err
+		}
+
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		res.Body = io.NopCloser(bytes.NewReader(body))
+		fields["status"] = res.StatusCode
+		if len(body) > 0 {
+			fields["response_body"] = MaskSecrets(body)
+		}
+		tflog.SubsystemDebug(ctx, LogSubsystem, "request", fields)
+
+		return res, nil
+	})
+}
+
+// logContext returns ctx with the LogSubsystem logger.
+func logContext(ctx context.Context) context.Context {
+	return tflog.NewSubsystem(ctx, LogSubsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_IOSXE", LogSubsystem))
+}
+
+// MaskSecrets returns body with the values of password and secret members
+// masked, as well as the secrets in CLI lines.
+func MaskSecrets(body []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return maskWords(string(body))
+	}
+
+	b, err := json.Marshal(maskValue(v))
+	if err != nil {
+		return maskWords(string(body))
+	}
+	return string(b)
+}
+
+func maskValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		r := map[string]interface{}{}
+		for k, e := range t {
+			if secretMember.MatchString(k) {
+				r[k] = "***"
+				continue
+			}
+			r[k] = maskValue(e)
+		}
+		return r
+	case []interface{}:
+		r := []interface{}{}
+		for _, e := range t {
+			r = append(r, maskValue(e))
+		}
+		return r
+	case string:
+		return maskWords(t)
+	}
+	return v
+}
+
+func maskWords(s string) string {
+	return secretWord.ReplaceAllString(s, "$1$2***")
+}
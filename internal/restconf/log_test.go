@@ -0,0 +1,63 @@
+package restconf
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	cases := []struct {
+		body string
+		want string
+	}{
+		{
+			body: `{"Cisco-IOS-XE-bgp:neighbor":{"id":"192.0.2.1","password":{"enctype":7,"text":"0822455D0A16"}}}`,
+			want: `{"Cisco-IOS-XE-bgp:neighbor":{"id":"192.0.2.1","password":"***"}}`,
+		},
+		{
+			body: `{"Cisco-IOS-XE-native:username":[{"name":"admin","secret":{"secret":"x"}}]}`,
+			want: `{"Cisco-IOS-XE-native:username":[{"name":"admin","secret":"***"}]}`,
+		},
+		{
+			body: `{"Cisco-IOS-XE-cli-rpc:input":{"clis":"username admin secret 9 abc\nneighbor 192.0.2.1 password 7 0822455D0A16"}}`,
+			want: `{"Cisco-IOS-XE-cli-rpc:input":{"clis":"username admin secret 9 ***\nneighbor 192.0.2.1 password 7 ***"}}`,
+		},
+		{
+			body: `enable secret foo`,
+			want: `enable secret ***`,
+		},
+		{
+			body: `{"Cisco-IOS-XE-native:hostname":"sw1"}`,
+			want: `{"Cisco-IOS-XE-native:hostname":"sw1"}`,
+		},
+	}
+
+	for _, c := range cases {
+		if got := MaskSecrets([]byte(c.body)); got != c.want {
+			t.Errorf("expected %s, got %s", c.want, got)
+		}
+	}
+}
+
+func TestLogTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	defer srv.Close()
+
+	c := &http.Client{Transport: LogTransport(http.DefaultTransport)}
+	body := `{"password":"foo"}`
+	res, err := c.Post(srv.URL, "application/yang-data+json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer res.Body.Close()
+
+	b, _ := io.ReadAll(res.Body)
+	if string(b) != body {
+		t.Fatalf("expected the bodies to pass unmasked, got %s", b)
+	}
+}
@@ -0,0 +1,686 @@
+// Package restconftest provides an in-memory IOS-XE RESTCONF server for tests.
+//
+// The server keeps a single YANG JSON tree and applies GET/HEAD/PUT/PATCH/DELETE
+// requests under /restconf/data to it. POSTs under /restconf/operations are
+// passed to the RPC handlers registered with HandleRPC. It knows nothing about the YANG schema:
+// path segments with keys ("definition=FOOBAR") address list entries by matching
+// the key values against the leaves of each entry, and everything else is a
+// container.
+package restconftest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	DataPath       = "/restconf/data/"
+	OperationsPath = "/restconf/operations/"
+	NativeRoot     = "Cisco-IOS-XE-native:native"
+
+	contentType = "application/yang-data+json"
+)
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Body   []byte
+}
+
+// Server is a fake IOS-XE RESTCONF device.
+type Server struct {
+	*httptest.Server
+
+	// Host is the host:port to configure the provider with.
+	Host string
+
+	mu       sync.Mutex
+	data     map[string]interface{}
+	requests []Request
+	rpcs     map[string]RPCHandler
+}
+
+// RPCHandler implements an operation. It gets the content of the input member
+// and returns the content of the output member, nil for no output. Errors are
+// returned as an operation-failed error response.
+type RPCHandler func(input map[string]interface{}) (map[string]interface{}, error)
+
+// NewServer starts a TLS server with an empty native config tree.
+func NewServer() *Server {
+	s := &Server{
+		data: map[string]interface{}{
+			NativeRoot: map[string]interface{}{},
+		},
+		rpcs: map[string]RPCHandler{},
+	}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	s.Host = strings.TrimPrefix(s.Server.URL, "https://")
+
+	return s
+}
+
+// Get returns the value stored at path, e.g. "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR".
+func (s *Server) Get(path string) (interface{}, bool) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, v, ok := lookup(s.data, segs)
+	return v, ok
+}
+
+// Exists reports whether any data is stored at path.
+func (s *Server) Exists(path string) bool {
+	_, ok := s.Get(path)
+	return ok
+}
+
+// Put stores the JSON body at path the same way a RESTCONF PUT would.
+func (s *Server) Put(path string, body string) error {
+	segs, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	name, value, err := decodeBody([]byte(body))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = put(s.data, segs, name, value)
+	return err
+}
+
+// Delete removes any data stored at path.
+func (s *Server) Delete(path string) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remove(s.data, segs)
+}
+
+// HandleRPC registers h for the operation name, e.g.
+// "Cisco-IOS-XE-cli-rpc:config-ios-cli-rpc". Handlers run without the server
+// lock held, so they can modify the data tree.
+func (s *Server) HandleRPC(name string, h RPCHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rpcs[name] = h
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// ResetRequests clears the recorded requests.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Body:   body,
+	})
+	s.mu.Unlock()
+
+	if r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, OperationsPath) {
+		s.handleRPC(w, strings.TrimPrefix(r.URL.Path, OperationsPath), body)
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.EscapedPath(), DataPath) {
+		writeError(w, http.StatusNotFound, "invalid-value", "uri keypath not found")
+		return
+	}
+
+	segs, err := parsePath(strings.TrimPrefix(r.URL.EscapedPath(), DataPath))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "malformed-message", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		s.handleGet(w, r, segs)
+	case http.MethodPut:
+		s.handlePut(w, segs, body)
+	case http.MethodPatch:
+		s.handlePatch(w, segs, body)
+	case http.MethodDelete:
+		s.handleDelete(w, segs)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "operation-not-supported", "method not allowed")
+	}
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request, segs []segment) {
+	name, v, ok := lookup(s.data, segs)
+	if !ok {
+		writeError(w, http.StatusNotFound, "invalid-value", "uri keypath not found")
+		return
+	}
+
+	b, err := json.Marshal(map[string]interface{}{name: v})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "operation-failed", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		w.Write(b)
+	}
+}
+
+func (s *Server) handlePut(w http.ResponseWriter, segs []segment, body []byte) {
+	name, value, err := decodeBody(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "malformed-message", err.Error())
+		return
+	}
+
+	created, err := put(s.data, segs, name, value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid-value", err.Error())
+		return
+	}
+
+	if created {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlePatch(w http.ResponseWriter, segs []segment, body []byte) {
+	_, value, err := decodeBody(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "malformed-message", err.Error())
+		return
+	}
+
+	if _, _, ok := lookup(s.data, segs); !ok {
+		writeError(w, http.StatusNotFound, "invalid-value", "patch to a nonexistent resource")
+		return
+	}
+
+	if err := patch(s.data, segs, value); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid-value", err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, segs []segment) {
+	if !remove(s.data, segs) {
+		writeError(w, http.StatusNotFound, "invalid-value", "uri keypath not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRPC(w http.ResponseWriter, name string, body []byte) {
+	s.mu.Lock()
+	h, ok := s.rpcs[name]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "invalid-value", "uri keypath not found")
+		return
+	}
+
+	input := map[string]interface{}{}
+	if len(body) > 0 {
+		_, v, err := decodeBody(body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "malformed-message", err.Error())
+			return
+		}
+		input, _ = v.(map[string]interface{})
+	}
+
+	output, err := h(input)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "operation-failed", err.Error())
+		return
+	}
+	if output == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	module := moduleName(name)
+	b, err := json.Marshal(map[string]interface{}{module + ":output": output})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "operation-failed", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+func writeError(w http.ResponseWriter, status int, tag string, msg string) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": map[string]interface{}{
+			"error": []interface{}{
+				map[string]interface{}{
+					"error-type":    "application",
+					"error-tag":     tag,
+					"error-message": msg,
+				},
+			},
+		},
+	})
+}
+
+// decodeBody returns the single top level member of a RESTCONF payload.
+func decodeBody(body []byte) (string, interface{}, error) {
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(body, &m); err != nil {
+		return "", nil, err
+	}
+	if len(m) != 1 {
+		return "", nil, fmt.Errorf("expected exactly one top level member, got %d", len(m))
+	}
+	for k, v := range m {
+		return k, v, nil
+	}
+
+	return "", nil, nil
+}
+
+type segment struct {
+	name string
+	keys []string
+}
+
+func (s segment) isListEntry() bool {
+	return len(s.keys) > 0
+}
+
+// parsePath splits an escaped RESTCONF data path into its segments.
+func parsePath(path string) ([]segment, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	segs := []segment{}
+	for _, p := range strings.Split(path, "/") {
+		seg := segment{}
+		name := p
+		if i := strings.Index(p, "="); i >= 0 {
+			name = p[:i]
+			for _, k := range strings.Split(p[i+1:], ",") {
+				key, err := url.PathUnescape(k)
+				if err != nil {
+					return nil, err
+				}
+				seg.keys = append(seg.keys, key)
+			}
+		}
+		n, err := url.PathUnescape(name)
+		if err != nil {
+			return nil, err
+		}
+		seg.name = n
+		segs = append(segs, seg)
+	}
+
+	return segs, nil
+}
+
+func localName(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func moduleName(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// child finds the member of m matching name regardless of module prefix.
+func child(m map[string]interface{}, name string) (string, interface{}, bool) {
+	for k, v := range m {
+		if localName(k) == localName(name) {
+			return k, v, true
+		}
+	}
+	return "", nil, false
+}
+
+func scalarString(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(t), true
+	}
+	return "", false
+}
+
+// matchesKeys reports whether every key value is held by one of the entry's leaves.
+func matchesKeys(entry interface{}, keys []string) bool {
+	m, ok := entry.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, key := range keys {
+		found := false
+		for _, v := range m {
+			if s, ok := scalarString(v); ok && s == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func findEntry(list []interface{}, keys []string) int {
+	for i, e := range list {
+		if matchesKeys(e, keys) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lookup returns the module qualified name and value at segs.
+func lookup(root map[string]interface{}, segs []segment) (string, interface{}, bool) {
+	var cur interface{} = root
+	module := ""
+	name := ""
+	for _, seg := range segs {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return "", nil, false
+		}
+		k, v, ok := child(m, seg.name)
+		if !ok {
+			return "", nil, false
+		}
+		if mod := moduleName(k); mod != "" {
+			module = mod
+		} else if mod := moduleName(seg.name); mod != "" {
+			module = mod
+		}
+		name = localName(k)
+		if seg.isListEntry() {
+			l, ok := v.([]interface{})
+			if !ok {
+				return "", nil, false
+			}
+			i := findEntry(l, seg.keys)
+			if i < 0 {
+				return "", nil, false
+			}
+			v = l[i]
+		}
+		cur = v
+	}
+	if module != "" {
+		name = module + ":" + name
+	}
+
+	return name, cur, true
+}
+
+// put replaces the value at segs, creating any missing containers on the way.
+func put(m map[string]interface{}, segs []segment, name string, value interface{}) (bool, error) {
+	seg := segs[0]
+	k, v, ok := child(m, seg.name)
+	if !ok {
+		k = seg.name
+	}
+
+	if len(segs) == 1 {
+		if ok {
+			delete(m, k)
+		}
+		if !seg.isListEntry() {
+			m[name] = value
+			return !ok, nil
+		}
+		if l, isList := value.([]interface{}); isList {
+			if len(l) != 1 {
+				return false, fmt.Errorf("expected a single list entry for %s", seg.name)
+			}
+			value = l[0]
+		}
+		l, _ := v.([]interface{})
+		created := false
+		if i := findEntry(l, seg.keys); i >= 0 {
+			l[i] = value
+		} else {
+			l = append(l, value)
+			created = true
+		}
+		if !ok {
+			k = name
+		}
+		m[k] = l
+		return created, nil
+	}
+
+	if !seg.isListEntry() {
+		next, isMap := v.(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[k] = next
+		} else if !isMap {
+			return false, fmt.Errorf("%s is not a container", seg.name)
+		}
+		return put(next, segs[1:], name, value)
+	}
+
+	l, _ := v.([]interface{})
+	i := findEntry(l, seg.keys)
+	if i < 0 {
+		return false, fmt.Errorf("missing element: %s=%s", seg.name, strings.Join(seg.keys, ","))
+	}
+	next, isMap := l[i].(map[string]interface{})
+	if !isMap {
+		return false, fmt.Errorf("%s is not a list entry", seg.name)
+	}
+	return put(next, segs[1:], name, value)
+}
+
+// patch merges value into the existing value at segs.
+func patch(m map[string]interface{}, segs []segment, value interface{}) error {
+	seg := segs[0]
+	k, v, ok := child(m, seg.name)
+	if !ok {
+		return fmt.Errorf("patch to a nonexistent resource")
+	}
+
+	if seg.isListEntry() {
+		l, _ := v.([]interface{})
+		i := findEntry(l, seg.keys)
+		if i < 0 {
+			return fmt.Errorf("patch to a nonexistent resource")
+		}
+		if len(segs) == 1 {
+			if nl, isList := value.([]interface{}); isList && len(nl) == 1 {
+				value = nl[0]
+			}
+			l[i] = merge(l[i], value)
+			return nil
+		}
+		next, isMap := l[i].(map[string]interface{})
+		if !isMap {
+			return fmt.Errorf("%s is not a list entry", seg.name)
+		}
+		return patch(next, segs[1:], value)
+	}
+
+	if len(segs) == 1 {
+		m[k] = merge(v, value)
+		return nil
+	}
+	next, isMap := v.(map[string]interface{})
+	if !isMap {
+		return fmt.Errorf("%s is not a container", seg.name)
+	}
+	return patch(next, segs[1:], value)
+}
+
+// merge deep merges src into dst. List entries are matched on their name, id
+// or af-name leaf, anything else is appended.
+func merge(dst interface{}, src interface{}) interface{} {
+	switch s := src.(type) {
+	case map[string]interface{}:
+		d, ok := dst.(map[string]interface{})
+		if !ok {
+			return s
+		}
+		for k, sv := range s {
+			dk, dv, ok := child(d, k)
+			if !ok {
+				d[k] = sv
+				continue
+			}
+			d[dk] = merge(dv, sv)
+		}
+		return d
+	case []interface{}:
+		d, ok := dst.([]interface{})
+		if !ok || isLeafList(s) {
+			return s
+		}
+		for _, se := range s {
+			i := findSameEntry(d, se)
+			if i < 0 {
+				d = append(d, se)
+				continue
+			}
+			d[i] = merge(d[i], se)
+		}
+		return d
+	}
+
+	return src
+}
+
+func isLeafList(l []interface{}) bool {
+	for _, e := range l {
+		if _, ok := e.(map[string]interface{}); ok {
+			return false
+		}
+	}
+	return true
+}
+
+func findSameEntry(list []interface{}, entry interface{}) int {
+	em, ok := entry.(map[string]interface{})
+	if !ok {
+		return -1
+	}
+	for _, key := range []string{"name", "id", "af-name"} {
+		ev, ok := em[key]
+		if !ok {
+			continue
+		}
+		for i, e := range list {
+			if m, ok := e.(map[string]interface{}); ok && reflect.DeepEqual(m[key], ev) {
+				return i
+			}
+		}
+		return -1
+	}
+	for i, e := range list {
+		if reflect.DeepEqual(e, entry) {
+			return i
+		}
+	}
+	return -1
+}
+
+// remove deletes the value at segs and reports whether there was one.
+func remove(m map[string]interface{}, segs []segment) bool {
+	seg := segs[0]
+	k, v, ok := child(m, seg.name)
+	if !ok {
+		return false
+	}
+
+	if seg.isListEntry() {
+		l, _ := v.([]interface{})
+		i := findEntry(l, seg.keys)
+		if i < 0 {
+			return false
+		}
+		if len(segs) == 1 {
+			m[k] = append(l[:i], l[i+1:]...)
+			return true
+		}
+		next, isMap := l[i].(map[string]interface{})
+		if !isMap {
+			return false
+		}
+		return remove(next, segs[1:])
+	}
+
+	if len(segs) == 1 {
+		delete(m, k)
+		return true
+	}
+	next, isMap := v.(map[string]interface{})
+	if !isMap {
+		return false
+	}
+	return remove(next, segs[1:])
+}
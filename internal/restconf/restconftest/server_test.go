@@ -0,0 +1,165 @@
+package restconftest
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func testDo(t *testing.T, s *Server, method string, path string, body string) (int, map[string]interface{}) {
+	req, err := http.NewRequest(method, s.URL+DataPath+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer res.Body.Close()
+
+	b, _ := io.ReadAll(res.Body)
+	out := map[string]interface{}{}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &out); err != nil {
+			t.Fatalf("unable to decode %q: %s", b, err)
+		}
+	}
+
+	return res.StatusCode, out
+}
+
+func TestServer_crud(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	path := "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR"
+
+	if code, _ := testDo(t, s, http.MethodGet, path, ""); code != http.StatusNotFound {
+		t.Fatalf("expected 404 before create, got %d", code)
+	}
+
+	code, _ := testDo(t, s, http.MethodPut, path, `{"Cisco-IOS-XE-native:definition": {"name": "FOOBAR", "rd": "1:1"}}`)
+	if code != http.StatusCreated {
+		t.Fatalf("expected 201 on create, got %d", code)
+	}
+	code, _ = testDo(t, s, http.MethodPut, path, `{"Cisco-IOS-XE-native:definition": {"name": "FOOBAR", "rd": "1:2", "description": "foo"}}`)
+	if code != http.StatusNoContent {
+		t.Fatalf("expected 204 on replace, got %d", code)
+	}
+
+	code, body := testDo(t, s, http.MethodGet, path, "")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	vrf := body["Cisco-IOS-XE-native:definition"].(map[string]interface{})
+	if vrf["rd"] != "1:2" || vrf["description"] != "foo" {
+		t.Fatalf("unexpected body %v", body)
+	}
+
+	code, _ = testDo(t, s, http.MethodPatch, path, `{"Cisco-IOS-XE-native:definition": {"description": "bar"}}`)
+	if code != http.StatusNoContent {
+		t.Fatalf("expected 204 on patch, got %d", code)
+	}
+	_, body = testDo(t, s, http.MethodGet, path, "")
+	vrf = body["Cisco-IOS-XE-native:definition"].(map[string]interface{})
+	if vrf["rd"] != "1:2" || vrf["description"] != "bar" {
+		t.Fatalf("patch did not merge, got %v", body)
+	}
+
+	if code, _ := testDo(t, s, http.MethodDelete, path+"/description", ""); code != http.StatusNoContent {
+		t.Fatalf("expected 204 on leaf delete, got %d", code)
+	}
+	if s.Exists(path + "/description") {
+		t.Fatalf("leaf still present after delete")
+	}
+
+	if code, _ := testDo(t, s, http.MethodDelete, path, ""); code != http.StatusNoContent {
+		t.Fatalf("expected 204 on delete, got %d", code)
+	}
+	if code, _ := testDo(t, s, http.MethodDelete, path, ""); code != http.StatusNotFound {
+		t.Fatalf("expected 404 on second delete, got %d", code)
+	}
+}
+
+func TestServer_patchNonexistent(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	code, body := testDo(t, s, http.MethodPatch, "Cisco-IOS-XE-native:native/vrf/definition=FOOBAR", `{"Cisco-IOS-XE-native:definition": {"name": "FOOBAR"}}`)
+	if code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", code)
+	}
+	if !strings.Contains(toJSON(body), "patch to a nonexistent resource") {
+		t.Fatalf("unexpected error body %v", body)
+	}
+}
+
+func TestServer_moduleQualifiedChildren(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	err := s.Put("Cisco-IOS-XE-native:native/router/Cisco-IOS-XE-bgp:bgp=65420", `{"Cisco-IOS-XE-bgp:bgp": {"id": 65420}}`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	code, _ := testDo(t, s, http.MethodPut, "Cisco-IOS-XE-native:native/router/Cisco-IOS-XE-bgp:bgp=65420/neighbor=7.7.7.7", `{"Cisco-IOS-XE-bgp:neighbor": {"id": "7.7.7.7", "remote-as": 8899}}`)
+	if code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+
+	code, body := testDo(t, s, http.MethodGet, "Cisco-IOS-XE-native:native/router/bgp=65420/neighbor=7.7.7.7", "")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if _, ok := body["Cisco-IOS-XE-bgp:neighbor"]; !ok {
+		t.Fatalf("expected module qualified neighbor, got %v", body)
+	}
+
+	// missing parent list entries are not created implicitly
+	code, _ = testDo(t, s, http.MethodPut, "Cisco-IOS-XE-native:native/router/Cisco-IOS-XE-bgp:bgp=1/neighbor=7.7.7.7", `{"Cisco-IOS-XE-bgp:neighbor": {"id": "7.7.7.7"}}`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", code)
+	}
+}
+
+func toJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestServer_rpc(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	s.HandleRPC("Cisco-IOS-XE-cli-rpc:config-ios-cli-rpc", func(input map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"result": input["clis"]}, nil
+	})
+
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	res, err := c.Post(s.URL+OperationsPath+"Cisco-IOS-XE-cli-rpc:config-ios-cli-rpc", contentType, strings.NewReader(`{"Cisco-IOS-XE-cli-rpc:input": {"clis": "hostname foo"}}`))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer res.Body.Close()
+	b, _ := io.ReadAll(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.StatusCode)
+	}
+	if string(b) != `{"Cisco-IOS-XE-cli-rpc:output":{"result":"hostname foo"}}` {
+		t.Fatalf("unexpected output %s", b)
+	}
+
+	res, err = c.Post(s.URL+OperationsPath+"Cisco-IOS-XE-rpc:reload", contentType, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown operation, got %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,34 @@
+package restconf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+)
+
+// TLSConfig returns the config of HTTPS connections to a device. The device
+// certificate is verified against the PEM encoded certificates in caPEM, the
+// system roots if empty, and serverName, the host of the request if empty.
+// The PEM encoded certPEM and keyPEM are presented as client certificate if
+// set.
+func TLSConfig(caPEM, certPEM, keyPEM []byte, serverName string) (*tls.Config, error) {
+	cfg := &tls.Config{ServerName: serverName}
+
+	if len(caPEM) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no PEM encoded CA certificates found")
+		}
+		cfg.RootCAs = pool
+	}
+
+	if len(certPEM) > 0 || len(keyPEM) > 0 {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate. %s", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
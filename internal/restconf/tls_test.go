@@ -0,0 +1,98 @@
+package restconf
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testClientCert returns a self-signed PEM encoded client certificate and key.
+func testClientCert(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "terraform"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestTLSConfig(t *testing.T) {
+	certPEM, keyPEM := testClientCert(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(certPEM)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	cases := []struct {
+		name       string
+		ca         []byte
+		cert, key  []byte
+		serverName string
+		ok         bool
+	}{
+		{name: "mutual", ca: caPEM, cert: certPEM, key: keyPEM, ok: true},
+		{name: "server name", ca: caPEM, cert: certPEM, key: keyPEM, serverName: "example.com", ok: true},
+		{name: "wrong server name", ca: caPEM, cert: certPEM, key: keyPEM, serverName: "wrong.example"},
+		{name: "no client cert", ca: caPEM},
+		{name: "system roots", cert: certPEM, key: keyPEM},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg, err := TLSConfig(c.ca, c.cert, c.key, c.serverName)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			client := NewHTTPClient(Options{TLSConfig: cfg})
+			res, err := client.Get(srv.URL)
+			if err == nil {
+				res.Body.Close()
+			}
+			if c.ok && err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if !c.ok && err == nil {
+				t.Fatalf("expected the connection to fail")
+			}
+		})
+	}
+
+	if _, err := TLSConfig([]byte("foo"), nil, nil, ""); err == nil {
+		t.Fatalf("expected an error for a CA without certificates")
+	}
+	if _, err := TLSConfig(nil, certPEM, nil, ""); err == nil {
+		t.Fatalf("expected an error for a client certificate without key")
+	}
+}
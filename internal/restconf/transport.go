@@ -0,0 +1,203 @@
+// Package restconf contains the HTTP plumbing the provider wraps around
+// go-ios-xe-sdk clients.
+package restconf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// MaxBackoff caps the wait between retries.
+const MaxBackoff = 30 * time.Second
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// NotFoundTransport turns a 404 response to a GET into a NotFoundError.
+// go-ios-xe-sdk reads treat a missing resource as an empty result, which can't
+// be told apart from a resource with nothing set.
+func NotFoundTransport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		res, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		if req.Method == http.MethodGet && res.StatusCode == http.StatusNotFound {
+			res.Body.Close()
+			return nil, &NotFoundError{Path: req.URL.Path}
+		}
+		return res, nil
+	})
+}
+
+// TokenTransport authenticates requests with token as bearer token, replacing
+// the basic authorization of the username and password.
+func TokenTransport(next http.RoundTripper, token string) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token)
+		return next.RoundTrip(req)
+	})
+}
+
+// LimitTransport allows at most n requests to be in flight at once.
+func LimitTransport(next http.RoundTripper, n int) http.RoundTripper {
+	sem := make(chan struct{}, n)
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		select {
+		case sem <- struct{}{}:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		res, err := next.RoundTrip(req)
+		if err != nil {
+			<-sem
+			return nil, err
+		}
+		// hold the slot until the caller is done with the body
+		res.Body = &releaseBody{ReadCloser: res.Body, release: func() { <-sem }}
+		return res, nil
+	})
+}
+
+// RetryTransport retries requests the device rejected because it was busy,
+// and idempotent requests that failed to connect, lost their connection or
+// timed out, waiting backoff, 2*backoff, 4*backoff... (capped at MaxBackoff)
+// between attempts. Each attempt is bounded by timeout when it is set.
+func RetryTransport(next http.RoundTripper, maxRetries int, backoff time.Duration, timeout time.Duration) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		for attempt := 0; ; attempt++ {
+			if attempt > 0 && req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req.Body = body
+			}
+
+			res, err := roundTripWithTimeout(next, req, timeout)
+			if attempt >= maxRetries {
+				return res, err
+			}
+
+			wait := backoff << attempt
+			if wait > MaxBackoff || wait <= 0 {
+				wait = MaxBackoff
+			}
+			fields := map[string]interface{}{
+				"method": req.Method,
+				"path":   req.URL.EscapedPath(),
+				"wait":   wait.String(),
+			}
+
+			switch {
+			case err != nil:
+				if !isRetryableError(req, err) {
+					return nil, err
+				}
+				fields["error"] = err.Error()
+				tflog.SubsystemDebug(logContext(req.Context()), LogSubsystem, "request failed, retrying", fields)
+			case isBusy(res):
+				fields["status"] = res.StatusCode
+				tflog.SubsystemDebug(logContext(req.Context()), LogSubsystem, "device busy, retrying", fields)
+				res.Body.Close()
+			default:
+				return res, nil
+			}
+
+			select {
+			case <-time.After(wait):
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+		}
+	})
+}
+
+func roundTripWithTimeout(next http.RoundTripper, req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if timeout <= 0 {
+		return next.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	res, err := next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = &releaseBody{ReadCloser: res.Body, release: cancel}
+	return res, nil
+}
+
+// isRetryableError reports whether the request failed in a way that is safe
+// to retry: an idempotent request that couldn't connect, lost its connection
+// or ran into the timeout of its attempt while its own context is still live.
+func isRetryableError(req *http.Request, err error) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isBusy reports whether the device refused the request because its
+// datastore was locked or it was otherwise too busy to serve it. The body is
+// left readable for the caller.
+func isBusy(res *http.Response) bool {
+	switch res.StatusCode {
+	case http.StatusServiceUnavailable, http.StatusTooManyRequests:
+		return true
+	case http.StatusConflict:
+	default:
+		return false
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	msg := strings.ToLower(string(body))
+	for _, s := range []string{"lock-denied", "in-use", "locked", "sync in progress", "busy"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
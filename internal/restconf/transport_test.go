@@ -0,0 +1,298 @@
+package restconf
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotFoundTransport(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	c := &http.Client{Transport: NotFoundTransport(http.DefaultTransport)}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/restconf/data/Cisco-IOS-XE-native:native/vrf/definition=FOOBAR", nil)
+	_, err := c.Do(req)
+	if !IsNotFound(err) {
+		t.Fatalf("expected NotFoundError for GET, got %v", err)
+	}
+
+	// deletes of missing resources are left to the sdk
+	req, _ = http.NewRequest(http.MethodDelete, srv.URL+"/restconf/data/Cisco-IOS-XE-native:native/vrf/definition=FOOBAR", nil)
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error for DELETE: %s", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for DELETE, got %d", res.StatusCode)
+	}
+}
+
+func TestTokenTransport(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	c := &http.Client{Transport: TokenTransport(http.DefaultTransport, "s3cr3t")}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/restconf/data/Cisco-IOS-XE-native:native", nil)
+	req.SetBasicAuth("admin", "admin")
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	res.Body.Close()
+
+	if got != "Bearer s3cr3t" {
+		t.Fatalf("expected the bearer token, got %q", got)
+	}
+	if req.Header.Get("Authorization") == got {
+		t.Fatalf("the caller's request was modified")
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		body     string
+		attempts int32
+	}{
+		{name: "unavailable", status: http.StatusServiceUnavailable, attempts: 3},
+		{name: "locked", status: http.StatusConflict, body: `{"errors": {"error": [{"error-tag": "lock-denied", "error-message": "database is locked"}]}}`, attempts: 3},
+		{name: "sync", status: http.StatusConflict, body: `{"errors": {"error": [{"error-tag": "in-use", "error-message": "inconsistency: confd sync in progress"}]}}`, attempts: 3},
+		{name: "exists", status: http.StatusConflict, body: `{"errors": {"error": [{"error-tag": "data-exists", "error-message": "object already exists"}]}}`, attempts: 1},
+		{name: "bad request", status: http.StatusBadRequest, attempts: 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != "payload" {
+					t.Errorf("attempt %d got body %q", atomic.LoadInt32(&attempts), body)
+				}
+				if atomic.AddInt32(&attempts, 1) < 3 {
+					w.WriteHeader(c.status)
+					w.Write([]byte(c.body))
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer srv.Close()
+
+			client := &http.Client{Transport: RetryTransport(http.DefaultTransport, 5, time.Millisecond, 0)}
+			req, _ := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader("payload"))
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			body, _ := io.ReadAll(res.Body)
+			res.Body.Close()
+
+			if got := atomic.LoadInt32(&attempts); got != c.attempts {
+				t.Fatalf("expected %d attempts, got %d", c.attempts, got)
+			}
+			if c.attempts == 1 && string(body) != c.body {
+				t.Fatalf("response body not passed through, got %q", body)
+			}
+		})
+	}
+}
+
+func TestRetryTransport_maxRetries(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: RetryTransport(http.DefaultTransport, 2, time.Millisecond, 0)}
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected last response to be returned, got %d", res.StatusCode)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRetryTransport_timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: RetryTransport(http.DefaultTransport, 0, time.Millisecond, 50*time.Millisecond)}
+	start := time.Now()
+	_, err := client.Get(srv.URL)
+	if err == nil {
+		t.Fatalf("expected timeout error")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("request was not cut off by the timeout")
+	}
+}
+
+func TestRetryTransport_connectionErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		method   string
+		attempts int32
+		err      bool
+	}{
+		{name: "get", method: http.MethodGet, attempts: 3},
+		{name: "put", method: http.MethodPut, attempts: 3},
+		{name: "delete", method: http.MethodDelete, attempts: 3},
+		{name: "patch", method: http.MethodPatch, attempts: 1, err: true},
+		{name: "post", method: http.MethodPost, attempts: 1, err: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) < 3 {
+					// drop the connection without a response
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer srv.Close()
+
+			client := &http.Client{Transport: RetryTransport(&http.Transport{DisableKeepAlives: true}, 5, time.Millisecond, 0)}
+			req, _ := http.NewRequest(c.method, srv.URL, strings.NewReader("payload"))
+			res, err := client.Do(req)
+			if c.err != (err != nil) {
+				t.Fatalf("expected error %t, got %v", c.err, err)
+			}
+			if err == nil {
+				res.Body.Close()
+			}
+
+			if got := atomic.LoadInt32(&attempts); got != c.attempts {
+				t.Fatalf("expected %d attempts, got %d", c.attempts, got)
+			}
+		})
+	}
+}
+
+func TestRetryTransport_timeoutRetried(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: RetryTransport(http.DefaultTransport, 5, time.Millisecond, 50*time.Millisecond)}
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	res.Body.Close()
+
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRetryTransport_cancelled(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := &http.Client{Transport: RetryTransport(http.DefaultTransport, 5, time.Millisecond, 0)}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Fatalf("expected the deadline of the caller not to be retried, got %d attempts", got)
+	}
+}
+
+func TestLimitTransport(t *testing.T) {
+	var inFlight, peak int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: LimitTransport(http.DefaultTransport, 2)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := client.Get(srv.URL)
+			if err != nil {
+				t.Errorf("err: %s", err)
+				return
+			}
+			io.ReadAll(res.Body)
+			res.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Fatalf("expected at most 2 concurrent requests, got %d", peak)
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// the request itself carries no context, like the ones built by the sdk
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err := WithContext(ctx, NewHTTPClient(Options{})).Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -39,6 +39,7 @@ func main() {
 	if debugMode {
 		// TODO: update this string with the full name of your provider as used in your configs
 		err := plugin.Debug(context.Background(), "registry.terraform.io/poroping/iosxe", opts)
+		provider.CommitStaged()
 		if err != nil {
 			log.Fatal(err.Error())
 		}
@@ -46,4 +47,6 @@ func main() {
 	}
 
 	plugin.Serve(opts)
+	// commit what no iosxe_commit committed before Terraform stops the provider
+	provider.CommitStaged()
 }